@@ -0,0 +1,102 @@
+package client
+
+import (
+	"slices"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// Capabilities describes what the server supports for the current session.
+// Fields the server does not announce in session.created are filled with the
+// limits documented for the current protocol version.
+type Capabilities struct {
+	ProtocolVersion    string
+	Model              string
+	InputAudioFormats  []string
+	OutputAudioFormats []string
+	ChatModes          []events.ChatMode
+	Video              bool // whether video frames are accepted in some chat mode
+	MaxFPS             int  // maximum video frames per second, 0 if video is not supported
+
+	// Announced reports whether the server sent explicit capabilities. If
+	// false, every field except ProtocolVersion and Model is a default.
+	Announced bool
+}
+
+// DefaultCapabilities returns the capabilities assumed for servers that do
+// not announce them.
+func DefaultCapabilities() Capabilities {
+	return Capabilities{
+		InputAudioFormats:  []string{"wav", "pcm"},
+		OutputAudioFormats: []string{"pcm", "mp3"},
+		ChatModes:          []events.ChatMode{events.ChatModeAudio, events.ChatModeVideoPassive},
+		Video:              true,
+		MaxFPS:             2,
+	}
+}
+
+// SupportsInputAudioFormat reports whether format can be used as input_audio_format.
+func (c Capabilities) SupportsInputAudioFormat(format string) bool {
+	return slices.Contains(c.InputAudioFormats, format)
+}
+
+// SupportsOutputAudioFormat reports whether format can be used as output_audio_format.
+func (c Capabilities) SupportsOutputAudioFormat(format string) bool {
+	return slices.Contains(c.OutputAudioFormats, format)
+}
+
+// SupportsChatMode reports whether mode can be used as beta_fields.chat_mode.
+func (c Capabilities) SupportsChatMode(mode events.ChatMode) bool {
+	return slices.Contains(c.ChatModes, mode)
+}
+
+// capabilitiesFromSession merges the capabilities announced in a
+// session.created session over the defaults.
+func capabilitiesFromSession(session *events.Session) *Capabilities {
+	c := DefaultCapabilities()
+	if session == nil {
+		return &c
+	}
+	c.ProtocolVersion, c.Model = session.ProtocolVersion, session.Model
+	announced := session.Capabilities
+	if announced == nil {
+		return &c
+	}
+	c.Announced = true
+	if len(announced.InputAudioFormats) > 0 {
+		c.InputAudioFormats = slices.Clone(announced.InputAudioFormats)
+	}
+	if len(announced.OutputAudioFormats) > 0 {
+		c.OutputAudioFormats = slices.Clone(announced.OutputAudioFormats)
+	}
+	if len(announced.ChatModes) > 0 {
+		c.ChatModes = slices.Clone(announced.ChatModes)
+		c.Video = c.SupportsChatMode(events.ChatModeVideoPassive) || c.SupportsChatMode(events.ChatModeVideoProactive)
+	}
+	if announced.Video != nil {
+		c.Video = *announced.Video
+	}
+	if announced.MaxFPS > 0 {
+		c.MaxFPS = announced.MaxFPS
+	}
+	if !c.Video {
+		c.MaxFPS = 0
+	}
+	return &c
+}
+
+// Capabilities returns the capabilities of the current session. The second
+// result is false until session.created has been received on the current
+// connection.
+func (r *realtimeClient) Capabilities() (Capabilities, bool) {
+	c := r.capabilities.Load()
+	if c == nil {
+		return Capabilities{}, false
+	}
+	// Copy the slices so callers cannot modify the shared value.
+	caps := *c
+	caps.InputAudioFormats = slices.Clone(c.InputAudioFormats)
+	caps.OutputAudioFormats = slices.Clone(c.OutputAudioFormats)
+	caps.ChatModes = slices.Clone(c.ChatModes)
+	return caps, true
+}
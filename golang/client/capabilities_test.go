@@ -0,0 +1,79 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+func TestCapabilitiesFromSession(t *testing.T) {
+	no := false
+	tests := []struct {
+		name    string
+		session *events.Session
+		want    func(c *Capabilities)
+	}{
+		{
+			name:    "nil session",
+			session: nil,
+			want:    func(c *Capabilities) {},
+		},
+		{
+			name:    "not announced",
+			session: &events.Session{Model: "glm-realtime", ProtocolVersion: "2025-01"},
+			want: func(c *Capabilities) {
+				c.Model, c.ProtocolVersion = "glm-realtime", "2025-01"
+			},
+		},
+		{
+			name: "partial",
+			session: &events.Session{Capabilities: &events.Capabilities{
+				InputAudioFormats: []string{"pcm"}, MaxFPS: 5,
+			}},
+			want: func(c *Capabilities) {
+				c.InputAudioFormats, c.MaxFPS, c.Announced = []string{"pcm"}, 5, true
+			},
+		},
+		{
+			name: "audio only chat modes",
+			session: &events.Session{Capabilities: &events.Capabilities{
+				ChatModes: []events.ChatMode{events.ChatModeAudio}, MaxFPS: 5,
+			}},
+			want: func(c *Capabilities) {
+				c.ChatModes, c.Video, c.MaxFPS, c.Announced = []events.ChatMode{events.ChatModeAudio}, false, 0, true
+			},
+		},
+		{
+			name: "video disabled",
+			session: &events.Session{Capabilities: &events.Capabilities{
+				Video: &no,
+			}},
+			want: func(c *Capabilities) {
+				c.Video, c.MaxFPS, c.Announced = false, 0, true
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := DefaultCapabilities()
+			tt.want(&want)
+			if got := capabilitiesFromSession(tt.session); !reflect.DeepEqual(*got, want) {
+				t.Errorf("capabilitiesFromSession() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesSupports(t *testing.T) {
+	c := DefaultCapabilities()
+	if !c.SupportsInputAudioFormat("wav") || c.SupportsInputAudioFormat("mp3") {
+		t.Error("SupportsInputAudioFormat")
+	}
+	if !c.SupportsOutputAudioFormat("mp3") || c.SupportsOutputAudioFormat("wav") {
+		t.Error("SupportsOutputAudioFormat")
+	}
+	if !c.SupportsChatMode(events.ChatModeVideoPassive) || c.SupportsChatMode(events.ChatModeVideoProactive) {
+		t.Error("SupportsChatMode")
+	}
+}
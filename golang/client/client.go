@@ -2,10 +2,12 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
@@ -28,12 +30,34 @@ type realtimeClient struct {
 	isConnected bool
 	lock        sync.RWMutex
 	wg          *sync.WaitGroup
+	readDone    chan struct{} // closed when the read loop of the current connection exits
+
+	onInternalError func(err error)
+	onRateLimit     func(status RateLimitStatus)
+	dump            atomic.Pointer[eventDumper]
+	eventLog        *EventLog
+	capabilities    atomic.Pointer[Capabilities]
+	sessionID       atomic.Value // string
+	lastEvent       atomic.Int64 // unix nanoseconds
+	rateLimits      atomic.Pointer[RateLimitStatus]
+	pinger          pinger
 }
 
-const waitTimeout = 30 * time.Second // Define a default timeout for wait
+var waitTimeout = 30 * time.Second // Define a default timeout for wait, a var so tests can shorten it
 
-func NewRealtimeClient(url, apiKey string, onReceived func(event *events.Event) error) *realtimeClient {
-	return &realtimeClient{url: url, apiKey: apiKey, onReceived: onReceived}
+var (
+	// ErrNotConnected is returned when sending on a client that is not connected.
+	ErrNotConnected = errors.New("client: not connected")
+	// ErrInvalidEvent is returned when an event is not valid for the called method.
+	ErrInvalidEvent = errors.New("client: invalid event")
+)
+
+func NewRealtimeClient(url, apiKey string, onReceived func(event *events.Event) error, opts ...Option) *realtimeClient {
+	r := &realtimeClient{url: url, apiKey: apiKey, onReceived: onReceived}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *realtimeClient) Connect() error {
@@ -50,16 +74,20 @@ func (r *realtimeClient) Connect() error {
 	c, rsp, err := websocket.DefaultDialer.Dial(r.url, header)
 	if err != nil {
 		log.Printf("[RealtimeClient] WebSocket dial fail, url: %s, rsp: %v, err: %v\n", r.url, rsp, err)
-		return err
+		return handshakeError(rsp, err)
 	}
 	c.SetCloseHandler(func(code int, reason string) error {
 		log.Printf("[RealtimeClient] WebSocket closed with code: %d, reason: %s\n", code, reason)
 		return nil
 	})
-	r.conn, r.isConnected, r.wg = c, true, &sync.WaitGroup{}
+	c.SetPongHandler(r.pinger.handlePong)
+	r.conn, r.isConnected, r.wg, r.readDone = c, true, &sync.WaitGroup{}, make(chan struct{})
+	r.capabilities.Store(nil)
+	r.sessionID.Store("")
+	r.rateLimits.Store(nil)
 
 	r.wg.Add(1)
-	go r.readWsMsg()
+	go r.readWsMsg(r.readDone, rateLimitsFromHeader(rsp.Header, time.Now()))
 
 	return nil
 }
@@ -103,12 +131,15 @@ func (r *realtimeClient) Send(event *events.Event) (err error) {
 	defer r.lock.RUnlock()
 	if !r.isConnected {
 		log.Printf("[RealtimeClient] Sending event fail, err: not connected\n")
-		return fmt.Errorf("not connected")
+		return ErrNotConnected
 	}
 	if event.ClientTimestamp <= 0 {
 		event.ClientTimestamp = time.Now().UnixMilli()
 	}
-	if err = r.conn.WriteMessage(websocket.TextMessage, []byte(event.ToJson())); err != nil {
+	message := []byte(event.ToJson())
+	r.dumpEvent(dumpSend, message)
+	r.logEvent(dumpSend, message)
+	if err = r.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 		log.Printf("[RealtimeClient] Send failed, error: %v\n", err)
 	}
 	return err
@@ -116,28 +147,31 @@ func (r *realtimeClient) Send(event *events.Event) (err error) {
 
 func (r *realtimeClient) SendFrameByVideo(event *events.Event) (err error) {
 	if events.RealtimeClientVideoAppend != event.Type {
-		return fmt.Errorf("event type is not RealtimeClientVideoAppend")
+		return fmt.Errorf("%w: type is %q, want %q", ErrInvalidEvent, event.Type, events.RealtimeClientVideoAppend)
 	}
 	if event.VideoFrame == nil {
-		return fmt.Errorf("event videoFrame is nil")
+		return fmt.Errorf("%w: video_frame is empty", ErrInvalidEvent)
 	}
 
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 	if !r.isConnected {
 		log.Printf("[RealtimeClient] Sending event fail, err: not connected\n")
-		return fmt.Errorf("not connected")
+		return ErrNotConnected
 	}
 	if event.ClientTimestamp <= 0 {
 		event.ClientTimestamp = time.Now().UnixMilli()
 	}
-	frames, err := tools.ExtractFramesToBase64(event.VideoFrame, "Z0LADJoFAAABMA==", "aM48gA==")
+	frames, err := tools.ExtractFramesToBase64(event.VideoFrame, tools.WithSPSPPS("Z0LADJoFAAABMA==", "aM48gA=="))
 	if err != nil {
-		return fmt.Errorf("extract frames failed: %v", err)
+		return fmt.Errorf("extract frames failed: %w", err)
 	}
 	for index := range frames {
 		event.VideoFrame = frames[index]
-		if err = r.conn.WriteMessage(websocket.TextMessage, []byte(event.ToJson())); err != nil {
+		message := []byte(event.ToJson())
+		r.dumpEvent(dumpSend, message)
+		r.logEvent(dumpSend, message)
+		if err = r.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 			log.Printf("[RealtimeClient] Send failed, error: %v\n", err)
 			return err
 		}
@@ -145,39 +179,59 @@ func (r *realtimeClient) SendFrameByVideo(event *events.Event) (err error) {
 	return nil
 }
 
-func (r *realtimeClient) readWsMsg() {
+// readWsMsg reads server events until the connection fails or is closed by
+// Disconnect, however long the session lasts; done is closed when it returns.
+// Reads have no deadline, so a quiet session stays connected; a dead peer is
+// detected by the ping in Health.
+// Rate limits from the handshake are reported first so the callback always
+// runs on this goroutine.
+func (r *realtimeClient) readWsMsg(done chan struct{}, handshakeLimits []RateLimit) {
 	defer r.wg.Done()
-	deadline := time.Now().Add(waitTimeout)
+	defer close(done)
+	defer r.recoverReadLoop()
+	r.updateRateLimits(handshakeLimits, time.Now())
 	for r.IsConnected() {
-		if time.Now().After(deadline) {
-			log.Printf("[RealtimeClient] ReadWsMsg loop time out after %v", waitTimeout)
-			return
-		}
-
-		if conn := r.conn; conn != nil {
-			if err := conn.SetReadDeadline(time.Now().Add(15 * time.Second)); err != nil {
-				log.Printf("[RealtimeClient] SetReadDeadline failed: %v", err)
-			}
-		}
 		messageType, message, err := r.conn.ReadMessage()
 		if err != nil {
 			log.Printf("[RealtimeClient] Read response failed, type: %d, message: %s, err: %v\n", messageType, string(message), err)
+			// A read failure after Disconnect is the expected way the loop ends.
+			if r.IsConnected() {
+				_ = r.Disconnect()
+				r.reportInternalError(fmt.Errorf("client: read message: %w", err))
+			}
 			return
 		}
 		// log.Printf("[RealtimeClient] Received message type: %d, message len: %d\n", messageType, len(message))
-		if r.onReceived == nil {
-			log.Printf("[RealtimeClient] OnReceived is nil, skipping...\n")
-			continue
-		}
 		event := &events.Event{}
-		if err = json.Unmarshal(message, event); err != nil {
+		err = json.Unmarshal(message, event)
+		// Record session.created under the id it announces.
+		if err == nil && event.Type == events.RealtimeServerEventSessionCreated && event.Session != nil {
+			r.sessionID.Store(event.Session.ID)
+		}
+		r.dumpEvent(dumpRecv, message)
+		r.logEvent(dumpRecv, message)
+		if err != nil {
 			log.Printf("[RealtimeClient] Unmarshal failed, err: %v\n", err)
 			_ = r.Disconnect()
+			r.reportInternalError(fmt.Errorf("%w: unmarshal server message: %w", ErrInvalidEvent, err))
 			return
 		}
-		if err = r.onReceived(event); err != nil {
+		r.lastEvent.Store(time.Now().UnixNano())
+		if event.Type == events.RealtimeServerEventSessionCreated {
+			r.capabilities.Store(capabilitiesFromSession(event.Session))
+		}
+		if event.Type == events.RealtimeServerEventRateLimitsUpdated {
+			now := time.Now()
+			r.updateRateLimits(rateLimitsFromEvent(event.RateLimits, now), now)
+		}
+		if r.onReceived == nil {
+			log.Printf("[RealtimeClient] OnReceived is nil, skipping...\n")
+			continue
+		}
+		if err = r.callOnReceived(event); err != nil {
 			log.Printf("[RealtimeClient] OnReceived failed, err: %v\n", err)
 			_ = r.Disconnect()
+			r.reportInternalError(fmt.Errorf("client: onReceived %s: %w", event.Type, err))
 			return
 		}
 	}
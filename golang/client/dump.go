@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+const (
+	dumpSend = "->"
+	dumpRecv = "<-"
+
+	// dumpMaxRaw limits how much of a message that is not valid JSON is dumped.
+	dumpMaxRaw = 256
+)
+
+var dumpRedactRules = DefaultRedactRules()
+
+// eventDumper serializes dump lines written by the sending and reading goroutines.
+type eventDumper struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// SetEventDump starts teeing every inbound and outbound event to w, one line
+// per event, or stops dumping if w is nil. It is safe to call at any time,
+// including while connected.
+//
+// Each line has the form
+//
+//	15:04:05.000 -> input_audio_buffer.append {"audio":"<9600 bytes>","client_timestamp":1700000000000}
+//
+// where "->" marks events sent to the server and "<-" events received from
+// it. Audio and video payloads are replaced by their decoded size. Messages
+// that are not valid JSON are dumped as a quoted, truncated string.
+func (r *realtimeClient) SetEventDump(w io.Writer) {
+	if w == nil {
+		r.dump.Store(nil)
+		return
+	}
+	r.dump.Store(&eventDumper{w: w})
+}
+
+// dumpEvent writes message to the event dump if it is enabled.
+func (r *realtimeClient) dumpEvent(direction string, message []byte) {
+	d := r.dump.Load()
+	if d == nil {
+		return
+	}
+	line := formatDumpLine(time.Now(), direction, message)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	_, _ = io.WriteString(d.w, line)
+}
+
+func formatDumpLine(t time.Time, direction string, message []byte) string {
+	var event map[string]any
+	dec := json.NewDecoder(bytes.NewReader(message))
+	dec.UseNumber()
+	if err := dec.Decode(&event); err != nil || event == nil {
+		raw := message
+		if len(raw) > dumpMaxRaw {
+			raw = raw[:dumpMaxRaw]
+		}
+		return fmt.Sprintf("%s %s <malformed> %q (%d bytes)\n", t.Format("15:04:05.000"), direction, raw, len(message))
+	}
+
+	eventType, _ := event["type"].(string)
+	delete(event, "type")
+	redact(event, events.EventType(eventType), dumpRedactRules)
+	// Empty fields such as the always present "delta" only add noise.
+	for key, value := range event {
+		if value == "" || value == nil {
+			delete(event, key)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s %s %s ", t.Format("15:04:05.000"), direction, eventType)
+	// Map keys are encoded in sorted order, which keeps lines diffable.
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(event); err != nil {
+		return fmt.Sprintf("%s<%v>\n", b.String(), err)
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+func TestFormatDumpLine(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 4, 5, 6e6, time.UTC)
+	audio := base64.StdEncoding.EncodeToString(make([]byte, 9600))
+	tests := []struct {
+		name      string
+		direction string
+		message   string
+		want      string
+	}{
+		{
+			name:      "audio append",
+			direction: dumpSend,
+			message:   `{"type":"input_audio_buffer.append","audio":"` + audio + `","delta":"","client_timestamp":1700000000000}`,
+			want:      `15:04:05.006 -> input_audio_buffer.append {"audio":"<9600 bytes>","client_timestamp":1700000000000}`,
+		},
+		{
+			name:      "audio delta",
+			direction: dumpRecv,
+			message:   `{"type":"response.audio.delta","response_id":"resp_1","delta":"AAA="}`,
+			want:      `15:04:05.006 <- response.audio.delta {"delta":"<2 bytes>","response_id":"resp_1"}`,
+		},
+		{
+			name:      "transcript delta is kept",
+			direction: dumpRecv,
+			message:   `{"type":"response.audio_transcript.delta","delta":"你好"}`,
+			want:      `15:04:05.006 <- response.audio_transcript.delta {"delta":"你好"}`,
+		},
+		{
+			name:      "nested payload",
+			direction: dumpSend,
+			message:   `{"type":"session.update","session":{"beta_fields":{"tts_cloned":{"audio":"AAAA","text":"hi"}}}}`,
+			want:      `15:04:05.006 -> session.update {"session":{"beta_fields":{"tts_cloned":{"audio":"<3 bytes>","text":"hi"}}}}`,
+		},
+		{
+			name:      "malformed",
+			direction: dumpRecv,
+			message:   `{"type":"response.cre`,
+			want:      `15:04:05.006 <- <malformed> "{\"type\":\"response.cre" (21 bytes)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDumpLine(at, tt.direction, []byte(tt.message)); got != tt.want+"\n" {
+				t.Errorf("formatDumpLine() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be read while the client writes to it.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return strings.Split(strings.TrimSuffix(b.buf.String(), "\n"), "\n")
+}
+
+func TestEventDumpToggle(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	received := make(chan events.EventType, 64)
+	buf := &syncBuffer{}
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		received <- event.Type
+		return nil
+	}, WithEventDump(buf))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	waitFor := func(eventType events.EventType) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case got := <-received:
+				if got == eventType {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %s", eventType)
+			}
+		}
+	}
+	waitFor(events.RealtimeServerEventSessionCreated)
+
+	audio := base64.StdEncoding.EncodeToString(make([]byte, 4800))
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventInputAudioBufferAppend, Audio: audio}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventInputAudioBufferCommit}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(events.RealtimeServerEventResponseDone)
+
+	lines := buf.lines()
+	if !strings.Contains(lines[0], "<- session.created ") {
+		t.Errorf("first line = %q, want session.created", lines[0])
+	}
+	if !strings.Contains(lines[1], `-> input_audio_buffer.append {"audio":"<4800 bytes>"`) {
+		t.Errorf("second line = %q, want elided audio append", lines[1])
+	}
+	if last := lines[len(lines)-1]; !strings.Contains(last, "<- response.done ") {
+		t.Errorf("last line = %q, want response.done", last)
+	}
+	for _, line := range lines {
+		if strings.Contains(line, audio[:64]) {
+			t.Errorf("audio payload not elided in %q", line)
+		}
+	}
+
+	c.SetEventDump(nil)
+	n := len(lines)
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(events.RealtimeServerEventResponseDone)
+	if got := len(buf.lines()); got != n {
+		t.Errorf("dump has %d lines after disabling, want %d", got, n)
+	}
+}
@@ -0,0 +1,213 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// ErrorClass groups server error codes by how a caller should react to them.
+type ErrorClass string
+
+const (
+	ErrorClassInvalidRequest ErrorClass = "invalid_request" // the request must be fixed before retrying
+	ErrorClassAuthentication ErrorClass = "authentication"  // the api key is missing, invalid or expired
+	ErrorClassPermission     ErrorClass = "permission"      // the account may not use the model or has no balance
+	ErrorClassContentBlocked ErrorClass = "content_blocked" // the input or output was rejected by content safety
+	ErrorClassRateLimited    ErrorClass = "rate_limited"    // too many requests, retry after backing off
+	ErrorClassQuotaExceeded  ErrorClass = "quota_exceeded"  // a usage quota is used up, retrying soon will not help
+	ErrorClassServer         ErrorClass = "server"          // the server failed, the request may succeed if retried
+	ErrorClassUnknown        ErrorClass = "unknown"
+)
+
+// Each error class has a sentinel error, so callers can test a *ServerError
+// with errors.Is(err, ErrRateLimited) and so on.
+var (
+	ErrInvalidRequest = errors.New("client: invalid request")
+	ErrAuthentication = errors.New("client: authentication failed")
+	ErrPermission     = errors.New("client: permission denied")
+	ErrContentBlocked = errors.New("client: content blocked")
+	ErrRateLimited    = errors.New("client: rate limited")
+	ErrQuotaExceeded  = errors.New("client: quota exceeded")
+	ErrServer         = errors.New("client: server error")
+	ErrUnknownServer  = errors.New("client: unknown server error")
+)
+
+// ErrorInfo describes how to handle one server error code.
+type ErrorInfo struct {
+	Code       string
+	Class      ErrorClass
+	Err        error  // sentinel error of Class
+	Message    string // message that can be shown to end users
+	Retryable  bool   // whether the same request may succeed if retried later
+	HTTPStatus int    // suggested status for bridge servers relaying the failure
+}
+
+var classInfo = map[ErrorClass]ErrorInfo{
+	ErrorClassInvalidRequest: {Class: ErrorClassInvalidRequest, Err: ErrInvalidRequest, Message: "请求参数有误", HTTPStatus: http.StatusBadRequest},
+	ErrorClassAuthentication: {Class: ErrorClassAuthentication, Err: ErrAuthentication, Message: "身份验证失败，请检查 API Key", HTTPStatus: http.StatusUnauthorized},
+	ErrorClassPermission:     {Class: ErrorClassPermission, Err: ErrPermission, Message: "无权访问该服务", HTTPStatus: http.StatusForbidden},
+	ErrorClassContentBlocked: {Class: ErrorClassContentBlocked, Err: ErrContentBlocked, Message: "内容可能包含不安全或敏感信息", HTTPStatus: http.StatusUnprocessableEntity},
+	ErrorClassRateLimited:    {Class: ErrorClassRateLimited, Err: ErrRateLimited, Message: "请求过于频繁，请稍后重试", Retryable: true, HTTPStatus: http.StatusTooManyRequests},
+	ErrorClassQuotaExceeded:  {Class: ErrorClassQuotaExceeded, Err: ErrQuotaExceeded, Message: "调用次数已达上限", HTTPStatus: http.StatusTooManyRequests},
+	ErrorClassServer:         {Class: ErrorClassServer, Err: ErrServer, Message: "服务暂时不可用，请稍后重试", Retryable: true, HTTPStatus: http.StatusBadGateway},
+	ErrorClassUnknown:        {Class: ErrorClassUnknown, Err: ErrUnknownServer, Message: "未知错误", HTTPStatus: http.StatusBadGateway},
+}
+
+// errorCodes lists the codes documented for the realtime api and the open
+// platform. Message overrides the class message when it is more specific.
+var errorCodes = map[string]struct {
+	class   ErrorClass
+	message string
+}{
+	"invalid_event":           {ErrorClassInvalidRequest, "事件格式有误"},
+	"video_model_query_error": {ErrorClassInvalidRequest, "视频模式下提交前需要至少上传一张图片"},
+	"500":                     {ErrorClassServer, ""},
+	"1000":                    {ErrorClassAuthentication, ""},
+	"1001":                    {ErrorClassAuthentication, "未提供 API Key"},
+	"1002":                    {ErrorClassAuthentication, "API Key 非法"},
+	"1003":                    {ErrorClassAuthentication, "API Key 已过期"},
+	"1004":                    {ErrorClassAuthentication, ""},
+	"1110":                    {ErrorClassPermission, "账户处于非活动状态"},
+	"1111":                    {ErrorClassPermission, "账户不存在"},
+	"1112":                    {ErrorClassPermission, "账户已被锁定"},
+	"1113":                    {ErrorClassPermission, "账户余额不足"},
+	"1210":                    {ErrorClassInvalidRequest, ""},
+	"1211":                    {ErrorClassInvalidRequest, "模型不存在"},
+	"1214":                    {ErrorClassInvalidRequest, ""},
+	"1220":                    {ErrorClassPermission, ""},
+	"1234":                    {ErrorClassServer, "网络错误，请稍后重试"},
+	"1301":                    {ErrorClassContentBlocked, ""},
+	"1302":                    {ErrorClassRateLimited, "并发数过高，请稍后重试"},
+	"1303":                    {ErrorClassRateLimited, ""},
+	"1304":                    {ErrorClassQuotaExceeded, "今日调用次数已达上限"},
+	"1305":                    {ErrorClassRateLimited, ""},
+}
+
+// errorTypes classifies codes missing from errorCodes by the error type.
+var errorTypes = map[string]ErrorClass{
+	"invalid_request_error": ErrorClassInvalidRequest,
+	"authentication_error":  ErrorClassAuthentication,
+	"permission_error":      ErrorClassPermission,
+	"rate_limit_error":      ErrorClassRateLimited,
+	"server_error":          ErrorClassServer,
+}
+
+// LookupError returns how to handle the server error with the given code and
+// type. Unknown codes are classified by errType, then as ErrorClassUnknown.
+func LookupError(code, errType string) ErrorInfo {
+	if c, ok := errorCodes[code]; ok {
+		info := classInfo[c.class]
+		info.Code = code
+		if c.message != "" {
+			info.Message = c.message
+		}
+		return info
+	}
+	class, ok := errorTypes[errType]
+	if !ok {
+		class = ErrorClassUnknown
+	}
+	info := classInfo[class]
+	info.Code = code
+	return info
+}
+
+// ErrorCodes returns the table of known error codes sorted by code.
+func ErrorCodes() []ErrorInfo {
+	infos := make([]ErrorInfo, 0, len(errorCodes))
+	for code := range errorCodes {
+		infos = append(infos, LookupError(code, ""))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code })
+	return infos
+}
+
+// ServerError is an error reported by the server, either in an "error" event
+// or in the response to a rejected websocket handshake. It wraps the sentinel
+// error of its class.
+type ServerError struct {
+	EventError events.EventError
+	EventID    string // id of the error event, empty for handshake failures
+	Info       ErrorInfo
+	cause      error
+}
+
+// NewServerError converts an "error" event to a *ServerError. It returns nil
+// for other events.
+func NewServerError(event *events.Event) *ServerError {
+	if event == nil || event.Type != events.RealtimeServerEventError || event.Error == nil {
+		return nil
+	}
+	return &ServerError{
+		EventError: *event.Error,
+		EventID:    event.EventID,
+		Info:       LookupError(event.Error.Code, event.Error.Type),
+	}
+}
+
+func (e *ServerError) Error() string {
+	var b strings.Builder
+	b.WriteString("server error")
+	if e.EventError.Code != "" {
+		fmt.Fprintf(&b, " %s", e.EventError.Code)
+	}
+	if e.EventError.Type != "" {
+		fmt.Fprintf(&b, " (%s)", e.EventError.Type)
+	}
+	if e.EventError.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.EventError.Message)
+	}
+	return b.String()
+}
+
+func (e *ServerError) Unwrap() []error {
+	if e.cause != nil {
+		return []error{e.Info.Err, e.cause}
+	}
+	return []error{e.Info.Err}
+}
+
+// handshakeError converts a rejected websocket handshake to a *ServerError,
+// using the error code in the response body when there is one.
+func handshakeError(rsp *http.Response, err error) error {
+	if rsp == nil || rsp.StatusCode < http.StatusBadRequest {
+		return err
+	}
+	var body struct {
+		Error events.EventError `json:"error"`
+	}
+	if rsp.Body != nil {
+		_ = json.NewDecoder(rsp.Body).Decode(&body)
+	}
+	serverErr := &ServerError{EventError: body.Error, cause: err}
+	if body.Error.Code == "" && body.Error.Type == "" {
+		serverErr.Info = infoForStatus(rsp.StatusCode)
+		serverErr.EventError.Message = http.StatusText(rsp.StatusCode)
+	} else {
+		serverErr.Info = LookupError(body.Error.Code, body.Error.Type)
+	}
+	return serverErr
+}
+
+func infoForStatus(status int) ErrorInfo {
+	class := ErrorClassUnknown
+	switch {
+	case status == http.StatusUnauthorized:
+		class = ErrorClassAuthentication
+	case status == http.StatusForbidden:
+		class = ErrorClassPermission
+	case status == http.StatusTooManyRequests:
+		class = ErrorClassRateLimited
+	case status >= http.StatusInternalServerError:
+		class = ErrorClassServer
+	case status >= http.StatusBadRequest:
+		class = ErrorClassInvalidRequest
+	}
+	return classInfo[class]
+}
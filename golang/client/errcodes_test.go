@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/gorilla/websocket"
+)
+
+func TestLookupError(t *testing.T) {
+	tests := []struct {
+		code, errType string
+		class         ErrorClass
+		retryable     bool
+		status        int
+	}{
+		{"invalid_event", "invalid_request_error", ErrorClassInvalidRequest, false, http.StatusBadRequest},
+		{"1002", "", ErrorClassAuthentication, false, http.StatusUnauthorized},
+		{"1113", "", ErrorClassPermission, false, http.StatusForbidden},
+		{"1301", "", ErrorClassContentBlocked, false, http.StatusUnprocessableEntity},
+		{"1302", "", ErrorClassRateLimited, true, http.StatusTooManyRequests},
+		{"1304", "", ErrorClassQuotaExceeded, false, http.StatusTooManyRequests},
+		{"500", "", ErrorClassServer, true, http.StatusBadGateway},
+		{"new_code", "server_error", ErrorClassServer, true, http.StatusBadGateway},
+		{"new_code", "invalid_request_error", ErrorClassInvalidRequest, false, http.StatusBadRequest},
+		{"new_code", "", ErrorClassUnknown, false, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		info := LookupError(tt.code, tt.errType)
+		if info.Code != tt.code || info.Class != tt.class || info.Retryable != tt.retryable || info.HTTPStatus != tt.status {
+			t.Errorf("LookupError(%q, %q) = %+v, want class %s retryable %v status %d",
+				tt.code, tt.errType, info, tt.class, tt.retryable, tt.status)
+		}
+		if info.Err == nil || info.Message == "" {
+			t.Errorf("LookupError(%q, %q) has no sentinel or message", tt.code, tt.errType)
+		}
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	infos := ErrorCodes()
+	if len(infos) != len(errorCodes) {
+		t.Fatalf("ErrorCodes() has %d entries, want %d", len(infos), len(errorCodes))
+	}
+	if !sort.SliceIsSorted(infos, func(i, j int) bool { return infos[i].Code < infos[j].Code }) {
+		t.Error("ErrorCodes() is not sorted by code")
+	}
+	for _, info := range infos {
+		if _, ok := classInfo[info.Class]; !ok || info.Err == nil || info.Message == "" || info.HTTPStatus == 0 {
+			t.Errorf("incomplete entry %+v", info)
+		}
+	}
+}
+
+func TestNewServerError(t *testing.T) {
+	if err := NewServerError(&events.Event{Type: events.RealtimeServerEventResponseDone}); err != nil {
+		t.Errorf("NewServerError(response.done) = %v, want nil", err)
+	}
+	err := NewServerError(&events.Event{
+		Type: events.RealtimeServerEventError, EventID: "evt_1",
+		Error: &events.EventError{Type: "invalid_request_error", Code: "1303", Message: "too fast"},
+	})
+	if !errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer) {
+		t.Errorf("errors.Is mismatch for %v", err)
+	}
+	if got, want := err.Error(), "server error 1303 (invalid_request_error): too fast"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err.EventID != "evt_1" || !err.Info.Retryable {
+		t.Errorf("NewServerError() = %+v", err)
+	}
+}
+
+func TestHandshakeError(t *testing.T) {
+	rsp := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+	}
+	if err := handshakeError(nil, websocket.ErrBadHandshake); err != websocket.ErrBadHandshake {
+		t.Errorf("handshakeError(nil) = %v", err)
+	}
+
+	err := handshakeError(rsp(http.StatusUnauthorized, `{"error":{"code":"1002","message":"invalid token"}}`), websocket.ErrBadHandshake)
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.Info.Code != "1002" || serverErr.EventError.Message != "invalid token" {
+		t.Fatalf("handshakeError() = %#v", err)
+	}
+	if !errors.Is(err, ErrAuthentication) || !errors.Is(err, websocket.ErrBadHandshake) {
+		t.Errorf("handshakeError() does not wrap both the class and the dial error: %v", err)
+	}
+
+	// 没有错误码时按 HTTP 状态码分类
+	err = handshakeError(rsp(http.StatusServiceUnavailable, "<html>busy</html>"), websocket.ErrBadHandshake)
+	if !errors.Is(err, ErrServer) || !errors.As(err, &serverErr) || !serverErr.Info.Retryable {
+		t.Errorf("handshakeError(503) = %v", err)
+	}
+}
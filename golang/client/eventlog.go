@@ -0,0 +1,245 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// eventLogTimeFormat is the timestamp inserted into rotated file names; it
+// sorts chronologically.
+const eventLogTimeFormat = "20060102T150405.000000"
+
+// EventLogConfig configures OpenEventLog.
+type EventLogConfig struct {
+	// Path of the active log file. Rotated files are renamed to Path with a
+	// timestamp inserted before the extension, events-20250102T150405.000000.jsonl.
+	Path string
+	// MaxSize rotates the file before a write would make it larger than
+	// MaxSize bytes; 0 disables size-based rotation.
+	MaxSize int64
+	// MaxAge rotates the file once it has been written to for MaxAge; 0
+	// disables age-based rotation. When an existing file is reopened, its
+	// age counts from its first record, or its modification time if that
+	// cannot be read, so the age carries over process restarts.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep, the oldest are
+	// removed; 0 keeps all of them.
+	MaxBackups int
+	// Redact is applied to every event before it is written. Nil means
+	// DefaultRedactRules; use an empty, non-nil slice to log payloads.
+	Redact []RedactRule
+}
+
+// EventLog writes the events of one or more clients to a file as JSON lines,
+// one record per event:
+//
+//	{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","event":{"type":"input_audio_buffer.append","audio":"<9600 bytes>"}}
+//
+// Messages that are not valid JSON are recorded with "malformed":true and
+// their size only, since they cannot be redacted. The log is independent of
+// the standard logger, so it can serve as a durable audit trail. It is safe
+// for concurrent use; share one EventLog between clients, see WithEventLog.
+type EventLog struct {
+	cfg EventLogConfig
+	now func() time.Time
+
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	closed bool
+}
+
+// eventLogRecord is one line of an EventLog.
+type eventLogRecord struct {
+	Time      time.Time      `json:"time"`
+	Direction string         `json:"direction"` // "send" or "recv"
+	SessionID string         `json:"session_id,omitempty"`
+	Event     map[string]any `json:"event,omitempty"`
+	Malformed bool           `json:"malformed,omitempty"`
+	Size      int            `json:"size,omitempty"` // only for malformed messages
+}
+
+// OpenEventLog opens or creates the log file at cfg.Path, appending to it.
+func OpenEventLog(cfg EventLogConfig) (*EventLog, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("client: event log path is empty")
+	}
+	if cfg.Redact == nil {
+		cfg.Redact = DefaultRedactRules()
+	}
+	l := &EventLog{cfg: cfg, now: time.Now}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *EventLog) open() error {
+	f, err := os.OpenFile(l.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("client: open event log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("client: open event log: %w", err)
+	}
+	l.file, l.size, l.opened = f, info.Size(), l.now()
+	if l.size > 0 {
+		l.opened = firstRecordTime(l.cfg.Path, info.ModTime())
+	}
+	return nil
+}
+
+// firstRecordTime returns the time of the first record in the log file at
+// path, or fallback if it cannot be read.
+func firstRecordTime(path string, fallback time.Time) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return fallback
+	}
+	defer f.Close()
+	var record struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.NewDecoder(f).Decode(&record); err != nil || record.Time.IsZero() {
+		return fallback
+	}
+	return record.Time
+}
+
+// Close closes the log file. Later events are dropped.
+func (l *EventLog) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return l.file.Close()
+}
+
+// log writes one message. Failures are only logged, they must not affect
+// the session.
+func (l *EventLog) log(direction, sessionID string, message []byte) {
+	line := l.format(l.now(), direction, sessionID, message)
+	if err := l.write(line); err != nil {
+		log.Printf("[RealtimeClient] Event log write failed, err: %v\n", err)
+	}
+}
+
+func (l *EventLog) format(t time.Time, direction, sessionID string, message []byte) []byte {
+	record := eventLogRecord{Time: t, Direction: "send", SessionID: sessionID}
+	if direction == dumpRecv {
+		record.Direction = "recv"
+	}
+	dec := json.NewDecoder(bytes.NewReader(message))
+	dec.UseNumber()
+	if err := dec.Decode(&record.Event); err != nil || record.Event == nil {
+		record.Event, record.Malformed, record.Size = nil, true, len(message)
+	} else {
+		eventType, _ := record.Event["type"].(string)
+		redact(record.Event, events.EventType(eventType), l.cfg.Redact)
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(record); err != nil {
+		b.Reset()
+		record.Event, record.Malformed, record.Size = nil, true, len(message)
+		_ = enc.Encode(record)
+	}
+	return b.Bytes()
+}
+
+func (l *EventLog) write(line []byte) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	if l.size > 0 && (l.cfg.MaxSize > 0 && l.size+int64(len(line)) > l.cfg.MaxSize ||
+		l.cfg.MaxAge > 0 && l.now().Sub(l.opened) >= l.cfg.MaxAge) {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotate renames the active file and opens a new one. It is called with the
+// lock held.
+func (l *EventLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("client: rotate event log: %w", err)
+	}
+	ext := filepath.Ext(l.cfg.Path)
+	base := strings.TrimSuffix(l.cfg.Path, ext)
+	renameErr := os.Rename(l.cfg.Path, base+"-"+l.now().UTC().Format(eventLogTimeFormat)+ext)
+	// Reopen even if the rename failed, so logging continues in the old file.
+	if err := l.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("client: rotate event log: %w", renameErr)
+	}
+	if l.cfg.MaxBackups > 0 {
+		backups, err := l.Backups()
+		if err != nil {
+			return err
+		}
+		for len(backups) > l.cfg.MaxBackups {
+			if err := os.Remove(backups[0]); err != nil {
+				return fmt.Errorf("client: rotate event log: %w", err)
+			}
+			backups = backups[1:]
+		}
+	}
+	return nil
+}
+
+// Backups returns the paths of the rotated log files, oldest first.
+func (l *EventLog) Backups() ([]string, error) {
+	dir, name := filepath.Split(l.cfg.Path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return nil, fmt.Errorf("client: list event log backups: %w", err)
+	}
+	var backups []string
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || e.IsDir() || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		if _, err := time.Parse(eventLogTimeFormat, strings.TrimSuffix(stamp, ext)); err == nil {
+			backups = append(backups, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The timestamps sort chronologically.
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// logEvent writes message to the event log if one is set.
+func (r *realtimeClient) logEvent(direction string, message []byte) {
+	if r.eventLog == nil {
+		return
+	}
+	sessionID, _ := r.sessionID.Load().(string)
+	r.eventLog.log(direction, sessionID, message)
+}
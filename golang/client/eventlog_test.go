@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+func readEventLog(t *testing.T, path string) []eventLogRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []eventLogRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record eventLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestEventLogFormat(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 4, 5, 6e6, time.UTC)
+	audio := base64.StdEncoding.EncodeToString(make([]byte, 9600))
+	tests := []struct {
+		name    string
+		rules   []RedactRule
+		message string
+		want    string
+	}{
+		{
+			name:    "default rules",
+			message: `{"type":"input_audio_buffer.append","audio":"` + audio + `","delta":""}`,
+			want:    `{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","event":{"audio":"<9600 bytes>","delta":"","type":"input_audio_buffer.append"}}`,
+		},
+		{
+			name: "custom rules",
+			rules: append(DefaultRedactRules(),
+				RedactRule{Field: "instructions", Action: RedactMask},
+				RedactRule{Field: "arguments", Types: []events.EventType{events.RealtimeServerEventResponseFunctionCallArgumentsDone}, Action: RedactRemove}),
+			message: `{"type":"session.update","session":{"instructions":"你是一个助手","beta_fields":{"tts_cloned":{"audio":"AAAA"}}}}`,
+			want:    `{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","event":{"session":{"beta_fields":{"tts_cloned":{"audio":"<3 bytes>"}},"instructions":"<redacted>"},"type":"session.update"}}`,
+		},
+		{
+			name:    "rule limited to other types",
+			rules:   []RedactRule{{Field: "arguments", Types: []events.EventType{events.RealtimeServerEventResponseFunctionCallArgumentsDone}, Action: RedactRemove}},
+			message: `{"type":"conversation.item.create","arguments":"{}"}`,
+			want:    `{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","event":{"arguments":"{}","type":"conversation.item.create"}}`,
+		},
+		{
+			name:    "no redaction",
+			rules:   []RedactRule{},
+			message: `{"type":"input_audio_buffer.append","audio":"AAAA"}`,
+			want:    `{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","event":{"audio":"AAAA","type":"input_audio_buffer.append"}}`,
+		},
+		{
+			name:    "malformed",
+			message: `{"type":"input_audio_buffer.append","audio":"AAAA`,
+			want:    `{"time":"2025-01-02T15:04:05.006Z","direction":"send","session_id":"sess_1","malformed":true,"size":49}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if rules == nil {
+				rules = DefaultRedactRules()
+			}
+			l := &EventLog{cfg: EventLogConfig{Redact: rules}}
+			if got := string(l.format(at, dumpSend, "sess_1", []byte(tt.message))); got != tt.want+"\n" {
+				t.Errorf("format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+	now := time.Date(2025, 1, 2, 15, 4, 5, 1e6, time.UTC)
+	message := []byte(`{"type":"response.created"}`)
+	l, err := OpenEventLog(EventLogConfig{Path: path, MaxAge: time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.now = func() time.Time { return now }
+	tick := func(d time.Duration) { now = now.Add(d) }
+	// 每个文件最多容纳 3 条记录
+	l.cfg.MaxSize = int64(3.5 * float64(len(l.format(now, dumpRecv, "sess_1", message))))
+	for i := 0; i < 3; i++ {
+		l.log(dumpRecv, "sess_1", message)
+		tick(time.Millisecond)
+	}
+	if backups, _ := l.Backups(); len(backups) != 0 {
+		t.Fatalf("rotated early: %v", backups)
+	}
+	l.log(dumpRecv, "sess_1", message) // exceeds MaxSize
+	backups, err := l.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || filepath.Base(backups[0]) != "events-20250102T150405.004000.jsonl" {
+		t.Fatalf("backups = %v, want one rotated by size", backups)
+	}
+	if n := len(readEventLog(t, backups[0])); n != 3 {
+		t.Errorf("rotated file has %d records, want 3", n)
+	}
+
+	tick(time.Hour) // exceeds MaxAge
+	l.log(dumpRecv, "sess_1", message)
+	for i := 0; i < 4; i++ {
+		tick(time.Millisecond)
+		l.log(dumpRecv, "sess_1", message)
+	}
+	backups, _ = l.Backups()
+	if len(backups) != 2 || !strings.Contains(backups[0], "T160405") {
+		t.Errorf("backups = %v, want the 2 newest", backups)
+	}
+	if n := len(readEventLog(t, path)); n != 2 {
+		t.Errorf("active file has %d records, want 2", n)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.log(dumpRecv, "sess_1", message) // dropped after Close
+}
+
+func TestEventLogReopenKeepsAge(t *testing.T) {
+	message := []byte(`{"type":"response.created"}`)
+	for _, tc := range []struct {
+		name  string
+		write func(t *testing.T, path string, l *EventLog)
+	}{
+		{"first record", func(t *testing.T, path string, l *EventLog) {
+			line := l.format(time.Now().Add(-2*time.Hour), dumpRecv, "sess_1", message)
+			if err := os.WriteFile(path, line, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"modification time", func(t *testing.T, path string, l *EventLog) {
+			if err := os.WriteFile(path, []byte("not json\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			old := time.Now().Add(-2 * time.Hour)
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			tc.write(t, path, &EventLog{cfg: EventLogConfig{Redact: DefaultRedactRules()}})
+			// 进程重启后重新打开已有文件，文件年龄不应从零开始
+			l, err := OpenEventLog(EventLogConfig{Path: path, MaxAge: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+			l.log(dumpRecv, "sess_1", message)
+			if backups, _ := l.Backups(); len(backups) != 1 {
+				t.Errorf("backups = %v, want the reopened file rotated by age", backups)
+			}
+			if n := len(readEventLog(t, path)); n != 1 {
+				t.Errorf("active file has %d records, want 1", n)
+			}
+		})
+	}
+}
+
+func TestEventLogClient(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l, err := OpenEventLog(EventLogConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan events.EventType, 64)
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		received <- event.Type
+		return nil
+	}, WithEventLog(l))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	waitFor := func(eventType events.EventType) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case got := <-received:
+				if got == eventType {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %s", eventType)
+			}
+		}
+	}
+	waitFor(events.RealtimeServerEventSessionCreated)
+	audio := base64.StdEncoding.EncodeToString(make([]byte, 4800))
+	for _, event := range []*events.Event{
+		{Type: events.RealtimeClientEventInputAudioBufferAppend, Audio: audio},
+		{Type: events.RealtimeClientEventInputAudioBufferCommit},
+		{Type: events.RealtimeClientEventResponseCreate},
+	} {
+		if err := c.Send(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(events.RealtimeServerEventResponseDone)
+
+	records := readEventLog(t, path)
+	if len(records) < 4 {
+		t.Fatalf("got %d records", len(records))
+	}
+	first, second := records[0], records[1]
+	session, _ := first.Event["session"].(map[string]any)
+	if first.Direction != "recv" || first.Event["type"] != "session.created" || session == nil ||
+		first.SessionID == "" || first.SessionID != session["id"] {
+		t.Errorf("first record = %+v, want received session.created with its session id", first)
+	}
+	if second.Direction != "send" || second.SessionID == "" || second.Event["audio"] != "<4800 bytes>" {
+		t.Errorf("second record = %+v, want redacted append with session id", second)
+	}
+	if last := records[len(records)-1]; last.Event["type"] != "response.done" {
+		t.Errorf("last record = %+v, want response.done", last)
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrSessionNotReady is returned by Health when the connection is up but the
+// server has not sent session.created yet.
+var ErrSessionNotReady = errors.New("client: session not ready")
+
+var errReadLoopExited = fmt.Errorf("client: ping: read loop exited: %w", ErrNotConnected)
+
+// HealthStatus is the result of a health check.
+type HealthStatus struct {
+	Connected bool          `json:"connected"`            // the websocket handshake, including auth, succeeded
+	SessionID string        `json:"session_id,omitempty"` // set once session.created has been received
+	RTT       time.Duration `json:"rtt_ns,omitempty"`     // ping round trip time, 0 if the ping failed
+	LastEvent *time.Time    `json:"last_event,omitempty"` // time the last server event was received, nil before the first
+	Error     string        `json:"error,omitempty"`      // why the check failed
+}
+
+// pinger matches pongs to the pings sent by Health.
+type pinger struct {
+	lock    sync.Mutex
+	next    uint64
+	pending map[string]chan struct{}
+}
+
+func (p *pinger) add() (string, chan struct{}) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.pending == nil {
+		p.pending = map[string]chan struct{}{}
+	}
+	p.next++
+	nonce, done := strconv.FormatUint(p.next, 10), make(chan struct{})
+	p.pending[nonce] = done
+	return nonce, done
+}
+
+func (p *pinger) remove(nonce string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.pending, nonce)
+}
+
+// handlePong is installed as the pong handler; it runs on the read loop.
+func (p *pinger) handlePong(nonce string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if done, ok := p.pending[nonce]; ok {
+		close(done)
+		delete(p.pending, nonce)
+	}
+	return nil
+}
+
+// Health checks that the client is connected, that the session has been
+// created and that the server answers a websocket ping before ctx is done.
+// The returned status is filled in as far as the check got; err is nil only
+// if every step succeeded. A successful Connect already implies the api key
+// was accepted, since the server rejects the handshake otherwise.
+func (r *realtimeClient) Health(ctx context.Context) (HealthStatus, error) {
+	status, err := r.health(ctx)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status, err
+}
+
+func (r *realtimeClient) health(ctx context.Context) (HealthStatus, error) {
+	var status HealthStatus
+	if at := r.lastEvent.Load(); at != 0 {
+		lastEvent := time.Unix(0, at)
+		status.LastEvent = &lastEvent
+	}
+	r.lock.RLock()
+	conn, connected, readDone := r.conn, r.isConnected, r.readDone
+	r.lock.RUnlock()
+	if !connected {
+		return status, ErrNotConnected
+	}
+	status.Connected = true
+	status.SessionID, _ = r.sessionID.Load().(string)
+	if status.SessionID == "" {
+		return status, ErrSessionNotReady
+	}
+
+	// Pongs are handled by the read loop, none will arrive once it has exited.
+	select {
+	case <-readDone:
+		status.Connected = false
+		return status, errReadLoopExited
+	default:
+	}
+
+	nonce, pong := r.pinger.add()
+	defer r.pinger.remove(nonce)
+	start := time.Now()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = start.Add(waitTimeout)
+	}
+	if err := conn.WriteControl(websocket.PingMessage, []byte(nonce), deadline); err != nil {
+		return status, fmt.Errorf("client: ping: %w", err)
+	}
+	select {
+	case <-pong:
+		status.RTT = time.Since(start)
+		return status, nil
+	case <-readDone:
+		status.Connected = false
+		return status, errReadLoopExited
+	case <-ctx.Done():
+		return status, fmt.Errorf("client: ping: %w", ctx.Err())
+	}
+}
+
+// HealthHandler returns an http.Handler for readiness probes. It responds
+// 200 when c.Health succeeds within timeout and 503 otherwise, with the
+// HealthStatus as JSON body.
+func HealthHandler(c interface {
+	Health(ctx context.Context) (HealthStatus, error)
+}, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		status, err := c.Health(ctx)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+// connectAndWait connects c and waits until it has received an event of type t.
+func connectAndWait(t *testing.T, server *mockserver.Server, eventType events.EventType) *realtimeClient {
+	t.Helper()
+	got := make(chan struct{}, 16)
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		if event.Type == eventType {
+			got <- struct{}{}
+		}
+		return nil
+	})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	select {
+	case <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", eventType)
+	}
+	return c
+}
+
+func TestHealth(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c := connectAndWait(t, server, events.RealtimeServerEventSessionCreated)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		status, err := c.Health(ctx)
+		if err != nil {
+			t.Fatalf("Health() err = %v, status %+v", err, status)
+		}
+		if !status.Connected || status.SessionID == "" || status.RTT <= 0 || status.LastEvent == nil || status.Error != "" {
+			t.Errorf("Health() = %+v", status)
+		}
+	}
+	if n := len(c.pinger.pending); n != 0 {
+		t.Errorf("%d pings still pending", n)
+	}
+
+	_ = c.Disconnect()
+	status, err := c.Health(ctx)
+	if !errors.Is(err, ErrNotConnected) || status.Connected || status.Error == "" {
+		t.Errorf("Health() after Disconnect = %+v, %v", status, err)
+	}
+}
+
+func TestHealthSessionNotReady(t *testing.T) {
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventSessionCreated}, Drop: 1,
+	}))
+	defer server.Close()
+	c := NewRealtimeClient(server.URL, "", nil)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	status, err := c.Health(context.Background())
+	if !errors.Is(err, ErrSessionNotReady) || !status.Connected || status.SessionID != "" {
+		t.Errorf("Health() = %+v, %v, want ErrSessionNotReady", status, err)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c := connectAndWait(t, server, events.RealtimeServerEventSessionCreated)
+	handler := HealthHandler(c, time.Second)
+
+	check := func(wantCode int) HealthStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != wantCode {
+			t.Errorf("status code = %d, want %d, body %s", rec.Code, wantCode, rec.Body)
+		}
+		var status HealthStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+	if status := check(http.StatusOK); status.RTT <= 0 {
+		t.Errorf("ready status = %+v", status)
+	}
+	_ = c.Disconnect()
+	if status := check(http.StatusServiceUnavailable); status.Connected || status.Error == "" {
+		t.Errorf("unready status = %+v", status)
+	}
+}
+
+func TestHealthAfterWaitTimeout(t *testing.T) {
+	// The read loop used to exit once waitTimeout had passed, leaving the
+	// client connected but deaf: later events and pongs were never read.
+	defer func(d time.Duration) { waitTimeout = d }(waitTimeout)
+	waitTimeout = 100 * time.Millisecond
+
+	server := mockserver.New()
+	defer server.Close()
+	done := make(chan struct{}, 16)
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		if event.Type == events.RealtimeServerEventResponseDone {
+			done <- struct{}{}
+		}
+		return nil
+	})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	for i := 0; i < 3; i++ {
+		time.Sleep(waitTimeout)
+		if err := c.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("response %d: timed out waiting for response.done", i)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if status, err := c.Health(ctx); err != nil {
+		t.Errorf("Health() = %+v, %v", status, err)
+	}
+}
+
+func TestHealthReadLoopExited(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c := connectAndWait(t, server, events.RealtimeServerEventSessionCreated)
+	// Simulate a read loop that has exited without disconnecting: Health
+	// must not wait for a pong nobody will read.
+	exited := make(chan struct{})
+	close(exited)
+	c.lock.Lock()
+	c.readDone = exited
+	c.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	status, err := c.Health(ctx)
+	if !errors.Is(err, ErrNotConnected) || status.Connected {
+		t.Errorf("Health() = %+v, %v, want ErrNotConnected", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Health() took %v, want it to return without waiting for the pong", elapsed)
+	}
+}
+
+func TestHealthIdleSession(t *testing.T) {
+	// Reads used to time out 15s after the last server event; pongs do not
+	// count as events, so a quiet session was dropped despite the probes.
+	if testing.Short() {
+		t.Skip("idles for more than 15s")
+	}
+	server := mockserver.New()
+	defer server.Close()
+	c := connectAndWait(t, server, events.RealtimeServerEventSessionCreated)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for deadline := time.Now().Add(16 * time.Second); time.Now().Before(deadline); time.Sleep(2 * time.Second) {
+		if status, err := c.Health(ctx); err != nil {
+			t.Fatalf("Health() = %+v, %v", status, err)
+		}
+	}
+	if !c.IsConnected() {
+		t.Fatal("idle session was disconnected")
+	}
+}
+
+func TestHealthStatusJSON(t *testing.T) {
+	data, err := json.Marshal(HealthStatus{Error: "client: not connected"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"connected":false,"error":"client: not connected"}`; string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
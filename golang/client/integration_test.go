@@ -0,0 +1,421 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/client"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+// 以下集成测试驱动完整的客户端连接进程内的 mock 服务端，不需要密钥和网络，可在 CI 中运行。
+
+const eventTimeout = 5 * time.Second
+
+// testClient 为测试用到的客户端方法集合
+type testClient interface {
+	client.RealtimeClient
+	IsConnected() bool
+	SendFrameByVideo(event *events.Event) error
+	Capabilities() (client.Capabilities, bool)
+}
+
+// recorder 收集客户端收到的全部服务端事件
+type recorder struct {
+	lock   sync.Mutex
+	events []*events.Event
+	notify chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{notify: make(chan struct{}, 1)}
+}
+
+func (r *recorder) onReceived(event *events.Event) error {
+	r.lock.Lock()
+	r.events = append(r.events, event)
+	r.lock.Unlock()
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (r *recorder) ofType(t events.EventType) []*events.Event {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var out []*events.Event
+	for _, e := range r.events {
+		if e.Type == t {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// waitFor 等待收到 n 个指定类型的事件
+func (r *recorder) waitFor(t *testing.T, eventType events.EventType, n int) []*events.Event {
+	t.Helper()
+	deadline := time.After(eventTimeout)
+	for {
+		if got := r.ofType(eventType); len(got) >= n {
+			return got
+		}
+		select {
+		case <-r.notify:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %d %s event(s), got %d", n, eventType, len(r.ofType(eventType)))
+		}
+	}
+}
+
+// tone 生成 24kHz 16 位单声道的 440Hz 正弦波 PCM，amplitude 为 0 时为静音
+func tone(ms int, amplitude float64) []byte {
+	n := 24000 * ms / 1000
+	pcm := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		v := amplitude * math.Sin(2*math.Pi*440*float64(i)/24000)
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(v*32767)))
+	}
+	return pcm
+}
+
+func connect(t *testing.T, server *mockserver.Server, apiKey string) (testClient, *recorder) {
+	t.Helper()
+	rec := newRecorder()
+	c := client.NewRealtimeClient(server.URL, apiKey, rec.onReceived)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Disconnect() })
+	rec.waitFor(t, events.RealtimeServerEventSessionCreated, 1)
+	return c, rec
+}
+
+func send(t *testing.T, c testClient, event *events.Event) {
+	t.Helper()
+	if err := c.Send(event); err != nil {
+		t.Fatalf("Send %s failed: %v", event.Type, err)
+	}
+}
+
+func appendAudio(t *testing.T, c testClient, pcm []byte) {
+	t.Helper()
+	for len(pcm) > 0 {
+		n := min(len(pcm), 4800)
+		send(t, c, &events.Event{Type: events.RealtimeClientEventInputAudioBufferAppend, Audio: base64.StdEncoding.EncodeToString(pcm[:n])})
+		pcm = pcm[n:]
+	}
+}
+
+func responseAudio(t *testing.T, rec *recorder) []byte {
+	t.Helper()
+	var audio []byte
+	for _, e := range rec.ofType(events.RealtimeServerEventResponseAudioDelta) {
+		pcm, err := base64.StdEncoding.DecodeString(e.Delta)
+		if err != nil {
+			t.Fatalf("decode audio delta: %v", err)
+		}
+		audio = append(audio, pcm...)
+	}
+	return audio
+}
+
+func TestIntegrationClientVad(t *testing.T) {
+	server := mockserver.New(mockserver.WithAPIKey("test-key"), mockserver.WithTranscript("你好"))
+	defer server.Close()
+	c, rec := connect(t, server, "test-key")
+
+	send(t, c, &events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{
+		InputAudioFormat: "pcm", OutputAudioFormat: "pcm", BetaFields: &events.BetaFields{ChatMode: events.ChatModeAudio},
+	}})
+	rec.waitFor(t, events.RealtimeServerEventSessionUpdated, 1)
+
+	input := tone(300, 0.5)
+	appendAudio(t, c, input)
+	send(t, c, &events.Event{Type: events.RealtimeClientEventInputAudioBufferCommit})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	done := rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+
+	if done[0].Response == nil || done[0].Response.Status != events.ResponseStatusCompleted {
+		t.Errorf("response.done = %+v, want completed response", done[0].Response)
+	}
+	if got := rec.ofType(events.RealtimeServerEventResponseAudioTranscriptDone); len(got) != 1 || *got[0].Transcript != "你好" {
+		t.Errorf("transcript events = %v", got)
+	}
+	if !bytes.Equal(responseAudio(t, rec), input) {
+		t.Error("response audio does not echo the committed input")
+	}
+}
+
+func TestIntegrationRejectsBadAPIKey(t *testing.T) {
+	server := mockserver.New(mockserver.WithAPIKey("test-key"))
+	defer server.Close()
+	c := client.NewRealtimeClient(server.URL, "wrong-key", newRecorder().onReceived)
+	err := c.Connect()
+	if err == nil {
+		_ = c.Disconnect()
+		t.Fatal("Connect with a wrong api key succeeded")
+	}
+	var serverErr *client.ServerError
+	if !errors.Is(err, client.ErrAuthentication) || !errors.As(err, &serverErr) || serverErr.Info.HTTPStatus != http.StatusUnauthorized {
+		t.Errorf("Connect() err = %v, want authentication ServerError", err)
+	}
+}
+
+func TestIntegrationServerVad(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	send(t, c, &events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{
+		TurnDetection: &events.TurnDetection{Type: "server_vad"},
+	}})
+	rec.waitFor(t, events.RealtimeServerEventSessionUpdated, 1)
+
+	speech := tone(400, 0.5)
+	appendAudio(t, c, tone(200, 0))
+	appendAudio(t, c, speech)
+	appendAudio(t, c, tone(200, 0))
+
+	rec.waitFor(t, events.RealtimeServerEventInputAudioBufferSpeechStarted, 1)
+	rec.waitFor(t, events.RealtimeServerEventInputAudioBufferSpeechStopped, 1)
+	rec.waitFor(t, events.RealtimeServerEventInputAudioBufferCommitted, 1)
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+	// 回声包含语音开始前的静音和检测到静音的第一个分片
+	if audio := responseAudio(t, rec); !bytes.Contains(audio, speech) {
+		t.Error("response audio does not contain the detected speech")
+	}
+}
+
+func TestIntegrationFunctionCall(t *testing.T) {
+	server := mockserver.New(mockserver.WithFunctionCall("search_engine", `{"q":"天气"}`))
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	send(t, c, &events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{
+		Tools: []events.Tool{{Type: "function", Name: "search_engine", Description: "搜索",
+			Parameters: events.ToolParameters{Type: "object", Properties: map[string]events.ToolProperty{"q": {Type: "string"}}, Required: []string{"q"}}}},
+	}})
+	appendAudio(t, c, tone(200, 0.5))
+	send(t, c, &events.Event{Type: events.RealtimeClientEventInputAudioBufferCommit})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+
+	call := rec.waitFor(t, events.RealtimeServerEventResponseFunctionCallArgumentsDone, 1)[0]
+	if call.Name != "search_engine" || call.Arguments != `{"q":"天气"}` || call.CallID == "" {
+		t.Fatalf("function call = %+v", call)
+	}
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+
+	output := `{"result":"晴"}`
+	send(t, c, &events.Event{Type: events.RealtimeClientEventConversationItemCreate, Item: &events.Item{
+		Type: events.ItemTypeFunctionCallOutput, CallId: call.CallID, Output: &output,
+	}})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 2)
+
+	items := server.ReceivedOfType(events.RealtimeClientEventConversationItemCreate)
+	if len(items) != 1 || items[0].Item.CallId != call.CallID {
+		t.Errorf("server received function output items %v", items)
+	}
+}
+
+func TestIntegrationVideoFrames(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	send(t, c, &events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{
+		BetaFields: &events.BetaFields{ChatMode: events.ChatModeVideoPassive},
+	}})
+	rec.waitFor(t, events.RealtimeServerEventSessionUpdated, 1)
+
+	jpg, err := os.ReadFile("../samples/files/pics/kunkun.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		send(t, c, &events.Event{Type: events.RealtimeClientVideoAppend, VideoFrame: jpg})
+	}
+	appendAudio(t, c, tone(200, 0.5))
+	send(t, c, &events.Event{Type: events.RealtimeClientEventInputAudioBufferCommit})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+
+	frames := server.ReceivedOfType(events.RealtimeClientVideoAppend)
+	if len(frames) != 3 || !bytes.Equal(frames[0].VideoFrame, jpg) {
+		t.Fatalf("server received %d video frames", len(frames))
+	}
+}
+
+func TestIntegrationSendFrameByVideo(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not found in PATH")
+	}
+	server := mockserver.New()
+	defer server.Close()
+	rec := newRecorder()
+	c := client.NewRealtimeClient(server.URL, "", rec.onReceived)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	rec.waitFor(t, events.RealtimeServerEventSessionCreated, 1)
+
+	h264, err := os.ReadFile("../tools/testdata/tiny.h264")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendFrameByVideo(&events.Event{Type: events.RealtimeClientVideoAppend, VideoFrame: h264}); err != nil {
+		t.Fatal(err)
+	}
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+	if frames := server.ReceivedOfType(events.RealtimeClientVideoAppend); len(frames) == 0 {
+		t.Fatal("no video frames received by server")
+	}
+}
+
+func TestIntegrationReconnect(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	rec := newRecorder()
+	c := client.NewRealtimeClient(server.URL, "", rec.onReceived)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	rec.waitFor(t, events.RealtimeServerEventSessionCreated, 1)
+
+	// 连接被服务端异常断开后，客户端应标记为未连接，并可以重新连接
+	server.CloseConnections()
+	deadline := time.Now().Add(eventTimeout)
+	for c.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("client still connected after the server dropped the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != client.ErrNotConnected {
+		t.Fatalf("Send after drop: err = %v, want ErrNotConnected", err)
+	}
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("reconnect failed: %v", err)
+	}
+	created := rec.waitFor(t, events.RealtimeServerEventSessionCreated, 2)
+	if created[0].Session.ID == created[1].Session.ID {
+		t.Error("reconnect reused the previous session id")
+	}
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+}
+
+func TestIntegrationDroppedEvents(t *testing.T) {
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventResponseAudioDelta}, Drop: 1,
+	}))
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	appendAudio(t, c, tone(300, 0.5))
+	send(t, c, &events.Event{Type: events.RealtimeClientEventInputAudioBufferCommit})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+	if n := len(rec.ofType(events.RealtimeServerEventResponseAudioDelta)); n != 0 {
+		t.Errorf("received %d audio deltas, want all dropped", n)
+	}
+	if !c.IsConnected() {
+		t.Error("client disconnected after dropped events")
+	}
+}
+
+func TestIntegrationDelayedEvents(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	server := mockserver.New()
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	server.SetFaults(mockserver.Fault{Delay: delay})
+	start := time.Now()
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	rec.waitFor(t, events.RealtimeServerEventResponseDone, 1)
+	// 空输入的响应至少包含 created、output_item.added 等 9 个事件
+	if elapsed := time.Since(start); elapsed < 9*delay {
+		t.Errorf("response took %v, want at least %v with injected delay", elapsed, 9*delay)
+	}
+}
+
+func TestIntegrationMalformedEvent(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c, rec := connect(t, server, "")
+
+	// 收到无法解析的事件时客户端主动断开
+	server.SetFaults(mockserver.Fault{Types: []events.EventType{events.RealtimeServerEventResponseCreated}, Malformed: 1})
+	send(t, c, &events.Event{Type: events.RealtimeClientEventResponseCreate})
+	deadline := time.Now().Add(eventTimeout)
+	for c.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("client still connected after a malformed event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(rec.ofType(events.RealtimeServerEventResponseDone)); n != 0 {
+		t.Errorf("received %d response.done events after the malformed event", n)
+	}
+}
+
+func TestIntegrationCapabilities(t *testing.T) {
+	server := mockserver.New(mockserver.WithCapabilities("2025-05", &events.Capabilities{
+		InputAudioFormats: []string{"pcm"},
+		ChatModes:         []events.ChatMode{events.ChatModeAudio},
+	}))
+	defer server.Close()
+	rec := newRecorder()
+	c := client.NewRealtimeClient(server.URL, "", rec.onReceived)
+	if _, ok := c.Capabilities(); ok {
+		t.Error("capabilities known before connecting")
+	}
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	rec.waitFor(t, events.RealtimeServerEventSessionCreated, 1)
+
+	caps, ok := c.Capabilities()
+	if !ok || !caps.Announced || caps.ProtocolVersion != "2025-05" || caps.Model != "mock-realtime" {
+		t.Fatalf("Capabilities() = %+v, %v", caps, ok)
+	}
+	if caps.Video || caps.MaxFPS != 0 || caps.SupportsInputAudioFormat("wav") {
+		t.Errorf("Capabilities() = %+v, want audio-only pcm input", caps)
+	}
+
+	// 不支持的格式会在运行时返回错误，应用应先检查能力
+	send(t, c, &events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{InputAudioFormat: "wav"}})
+	serverErr := client.NewServerError(rec.waitFor(t, events.RealtimeServerEventError, 1)[0])
+	if !errors.Is(serverErr, client.ErrInvalidRequest) || serverErr.Info.Code != "1214" || serverErr.Info.Retryable {
+		t.Errorf("error = %v, want non-retryable invalid parameter 1214", serverErr)
+	}
+}
+
+func TestIntegrationDefaultCapabilities(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	c, _ := connect(t, server, "")
+	caps, ok := c.Capabilities()
+	if !ok || caps.Announced || !caps.Video || caps.MaxFPS != client.DefaultCapabilities().MaxFPS {
+		t.Errorf("Capabilities() = %+v, %v, want defaults", caps, ok)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import "io"
+
+// Option configures a client created by NewRealtimeClient.
+type Option func(*realtimeClient)
+
+// WithEventDump tees every inbound and outbound event to w in a one-line
+// format, see SetEventDump.
+func WithEventDump(w io.Writer) Option {
+	return func(r *realtimeClient) {
+		r.SetEventDump(w)
+	}
+}
+
+// WithOnInternalError sets a callback for failures that happen on the
+// client's own goroutines and therefore cannot be returned to the caller:
+// panics recovered as *PanicError, unexpected read failures, malformed
+// server messages and errors returned by onReceived. The client has already
+// disconnected when fn is called. Without a callback these are only logged.
+func WithOnInternalError(fn func(err error)) Option {
+	return func(r *realtimeClient) {
+		r.onInternalError = fn
+	}
+}
+
+// WithOnRateLimit sets a callback that receives the merged rate limit status
+// whenever the server reports limits, first from the handshake response
+// headers and then from rate_limits.updated events. It runs on the read
+// goroutine and should not block.
+func WithOnRateLimit(fn func(status RateLimitStatus)) Option {
+	return func(r *realtimeClient) {
+		r.onRateLimit = fn
+	}
+}
+
+// WithEventLog writes every inbound and outbound event to l, see EventLog.
+// The client does not close l, so one log can be shared by many clients.
+func WithEventLog(l *EventLog) Option {
+	return func(r *realtimeClient) {
+		r.eventLog = l
+	}
+}
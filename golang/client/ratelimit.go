@@ -0,0 +1,150 @@
+package client
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// Well-known rate limit names. The server may report others, which are kept
+// in RateLimitStatus.Limits.
+const (
+	RateLimitRequests     = "requests"
+	RateLimitTokens       = "tokens"
+	RateLimitAudioSeconds = "audio_seconds"
+)
+
+// RateLimit is the state of one limit at the time it was reported.
+type RateLimit struct {
+	Name      string
+	Limit     int
+	Remaining int
+	ResetAt   time.Time // when Remaining is restored to Limit, zero if unknown
+}
+
+// RateLimitStatus collects the limits reported in the handshake response
+// headers and in rate_limits.updated events. Later reports replace earlier
+// ones with the same name.
+type RateLimitStatus struct {
+	Limits    []RateLimit // sorted by name
+	UpdatedAt time.Time
+}
+
+// Get returns the limit with the given name.
+func (s RateLimitStatus) Get(name string) (RateLimit, bool) {
+	i, ok := slices.BinarySearchFunc(s.Limits, name, func(l RateLimit, name string) int {
+		return strings.Compare(l.Name, name)
+	})
+	if !ok {
+		return RateLimit{}, false
+	}
+	return s.Limits[i], true
+}
+
+// Requests returns the "requests" limit.
+func (s RateLimitStatus) Requests() (RateLimit, bool) { return s.Get(RateLimitRequests) }
+
+// AudioSeconds returns the "audio_seconds" limit.
+func (s RateLimitStatus) AudioSeconds() (RateLimit, bool) { return s.Get(RateLimitAudioSeconds) }
+
+// Exhausted reports whether any limit has less than fraction of its capacity
+// remaining, e.g. Exhausted(0.1) when below 10%. Schedulers can use it to
+// stop starting new sessions before requests begin to fail.
+func (s RateLimitStatus) Exhausted(fraction float64) bool {
+	for _, l := range s.Limits {
+		if l.Limit > 0 && float64(l.Remaining) < fraction*float64(l.Limit) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns s updated with limits, without modifying s.
+func (s RateLimitStatus) merge(limits []RateLimit, at time.Time) RateLimitStatus {
+	merged := slices.Clone(s.Limits)
+	for _, l := range limits {
+		i, ok := slices.BinarySearchFunc(merged, l.Name, func(l RateLimit, name string) int {
+			return strings.Compare(l.Name, name)
+		})
+		if ok {
+			merged[i] = l
+		} else {
+			merged = slices.Insert(merged, i, l)
+		}
+	}
+	return RateLimitStatus{Limits: merged, UpdatedAt: at}
+}
+
+func rateLimitsFromEvent(limits []events.RateLimit, at time.Time) []RateLimit {
+	out := make([]RateLimit, 0, len(limits))
+	for _, l := range limits {
+		r := RateLimit{Name: l.Name, Limit: l.Limit, Remaining: l.Remaining}
+		if l.ResetSeconds > 0 {
+			r.ResetAt = at.Add(time.Duration(float64(l.ResetSeconds) * float64(time.Second)))
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// rateLimitsFromHeader parses x-ratelimit-{limit,remaining,reset}-<name>
+// headers. The reset value is a duration such as "1s" or "6m0s", or a number
+// of seconds. Dashes in names are reported as underscores.
+func rateLimitsFromHeader(header http.Header, at time.Time) []RateLimit {
+	const prefix = "X-Ratelimit-Limit-"
+	var out []RateLimit
+	for key := range header {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		suffix := key[len(prefix):]
+		limit, err := strconv.Atoi(header.Get(key))
+		if err != nil {
+			continue
+		}
+		l := RateLimit{Name: strings.ReplaceAll(strings.ToLower(suffix), "-", "_"), Limit: limit, Remaining: limit}
+		if remaining, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining-" + suffix)); err == nil {
+			l.Remaining = remaining
+		}
+		if reset := header.Get("X-Ratelimit-Reset-" + suffix); reset != "" {
+			if d, err := time.ParseDuration(reset); err == nil {
+				l.ResetAt = at.Add(d)
+			} else if secs, err := strconv.ParseFloat(reset, 64); err == nil {
+				l.ResetAt = at.Add(time.Duration(secs * float64(time.Second)))
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// RateLimits returns the latest rate limit status of the current connection.
+// The second result is false if the server has not reported any limit.
+func (r *realtimeClient) RateLimits() (RateLimitStatus, bool) {
+	s := r.rateLimits.Load()
+	if s == nil {
+		return RateLimitStatus{}, false
+	}
+	return RateLimitStatus{Limits: slices.Clone(s.Limits), UpdatedAt: s.UpdatedAt}, true
+}
+
+// updateRateLimits merges limits into the current status and passes the
+// result to the rate limit callback. It runs on the read loop.
+func (r *realtimeClient) updateRateLimits(limits []RateLimit, at time.Time) {
+	if len(limits) == 0 {
+		return
+	}
+	var status RateLimitStatus
+	if s := r.rateLimits.Load(); s != nil {
+		status = *s
+	}
+	status = status.merge(limits, at)
+	r.rateLimits.Store(&status)
+	if r.onRateLimit != nil {
+		r.onRateLimit(RateLimitStatus{Limits: slices.Clone(status.Limits), UpdatedAt: status.UpdatedAt})
+	}
+}
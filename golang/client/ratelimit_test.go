@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+func TestRateLimitsFromHeader(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set("X-Ratelimit-Limit-Requests", "100")
+	header.Set("X-Ratelimit-Remaining-Requests", "99")
+	header.Set("X-Ratelimit-Reset-Requests", "1m0s")
+	header.Set("X-Ratelimit-Limit-Audio-Seconds", "3600")
+	header.Set("X-Ratelimit-Reset-Audio-Seconds", "1.5")
+	header.Set("X-Ratelimit-Limit-Tokens", "lots") // ignored
+	header.Set("X-Request-Id", "abc")
+
+	got := rateLimitsFromHeader(header, at)
+	sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+	want := []RateLimit{
+		{Name: "audio_seconds", Limit: 3600, Remaining: 3600, ResetAt: at.Add(1500 * time.Millisecond)},
+		{Name: "requests", Limit: 100, Remaining: 99, ResetAt: at.Add(time.Minute)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rateLimitsFromHeader() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	var s RateLimitStatus
+	s = s.merge([]RateLimit{{Name: "tokens", Limit: 1000, Remaining: 900}, {Name: "requests", Limit: 10, Remaining: 5}}, at)
+	s = s.merge(rateLimitsFromEvent([]events.RateLimit{
+		{Name: "requests", Limit: 10, Remaining: 4, ResetSeconds: 2},
+		{Name: "audio_seconds", Limit: 60, Remaining: 30},
+	}, at), at)
+
+	var names []string
+	for _, l := range s.Limits {
+		names = append(names, l.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"audio_seconds", "requests", "tokens"}) {
+		t.Fatalf("limits = %v, want sorted and merged", names)
+	}
+	if r, ok := s.Requests(); !ok || r.Remaining != 4 || !r.ResetAt.Equal(at.Add(2*time.Second)) {
+		t.Errorf("Requests() = %+v, %v", r, ok)
+	}
+	if a, ok := s.AudioSeconds(); !ok || a.Remaining != 30 || !a.ResetAt.IsZero() {
+		t.Errorf("AudioSeconds() = %+v, %v", a, ok)
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Get(missing) found a limit")
+	}
+	if !s.Exhausted(0.5) || s.Exhausted(0.4) {
+		t.Errorf("Exhausted: requests at 40%% should be below 0.5 and not below 0.4")
+	}
+}
+
+func TestRateLimitCallback(t *testing.T) {
+	server := mockserver.New(mockserver.WithRateLimits(
+		events.RateLimit{Name: "requests", Limit: 10, Remaining: 2, ResetSeconds: 60},
+		events.RateLimit{Name: "audio_seconds", Limit: 600, Remaining: 600, ResetSeconds: 60},
+	))
+	defer server.Close()
+	updates := make(chan RateLimitStatus, 8)
+	done := make(chan struct{}, 8)
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		if event.Type == events.RealtimeServerEventResponseDone {
+			done <- struct{}{}
+		}
+		return nil
+	}, WithOnRateLimit(func(status RateLimitStatus) { updates <- status }))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	next := func() RateLimitStatus {
+		t.Helper()
+		select {
+		case s := <-updates:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for rate limit update")
+			return RateLimitStatus{}
+		}
+	}
+	// 握手响应头中的限额
+	first := next()
+	if r, ok := first.Requests(); !ok || r.Remaining != 2 || r.ResetAt.IsZero() {
+		t.Errorf("handshake requests limit = %+v, %v", r, ok)
+	}
+	if a, ok := first.AudioSeconds(); !ok || a.Limit != 600 {
+		t.Errorf("handshake audio_seconds limit = %+v, %v", a, ok)
+	}
+
+	for want := 1; want >= 0; want-- {
+		if err := c.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != nil {
+			t.Fatal(err)
+		}
+		<-done
+		status := next()
+		if r, _ := status.Requests(); r.Remaining != want {
+			t.Errorf("requests remaining = %d, want %d", r.Remaining, want)
+		}
+	}
+	status, ok := c.RateLimits()
+	if !ok || !status.Exhausted(0.1) {
+		t.Errorf("RateLimits() = %+v, %v, want exhausted", status, ok)
+	}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// PanicError is reported to the internal error handler when the read loop or
+// the onReceived callback panics. The panic is recovered so that one broken
+// session cannot take down a process hosting many of them.
+type PanicError struct {
+	Value any    // value passed to panic
+	Stack []byte // stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("client: panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// reportInternalError passes err to the internal error handler. A panic in
+// the handler itself is logged and dropped.
+func (r *realtimeClient) reportInternalError(err error) {
+	if r.onInternalError == nil {
+		if p, ok := err.(*PanicError); ok {
+			log.Printf("[RealtimeClient] Internal error: %v\n%s", p, p.Stack)
+		}
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("[RealtimeClient] OnInternalError panicked: %v\n%s", v, debug.Stack())
+		}
+	}()
+	r.onInternalError(err)
+}
+
+// recoverReadLoop stops a panicking read loop, disconnects and reports the panic.
+func (r *realtimeClient) recoverReadLoop() {
+	if v := recover(); v != nil {
+		_ = r.Disconnect()
+		r.reportInternalError(&PanicError{Value: v, Stack: debug.Stack()})
+	}
+}
+
+// callOnReceived calls onReceived, converting a panic to a *PanicError.
+func (r *realtimeClient) callOnReceived(event *events.Event) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return r.onReceived(event)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) { panic("dump writer broken") }
+
+// waitInternalError returns the first error passed to OnInternalError.
+func waitInternalError(t *testing.T, errs <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for internal error")
+		return nil
+	}
+}
+
+func TestRecoverHandlerPanic(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+
+	errs := make(chan error, 4)
+	broken := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		var session *events.Session
+		_ = session.ID // nil pointer dereference
+		return nil
+	}, WithOnInternalError(func(err error) { errs <- err }))
+	healthyDone := make(chan struct{}, 1)
+	healthy := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		if event.Type == events.RealtimeServerEventResponseDone {
+			healthyDone <- struct{}{}
+		}
+		return nil
+	})
+	for _, c := range []*realtimeClient{broken, healthy} {
+		if err := c.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		defer c.Disconnect()
+	}
+
+	err := waitInternalError(t, errs)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || !strings.Contains(string(panicErr.Stack), "TestRecoverHandlerPanic") {
+		t.Fatalf("internal error = %v, want *PanicError with handler stack", err)
+	}
+	if !strings.Contains(err.Error(), "session.created") {
+		t.Errorf("internal error %q does not name the event", err)
+	}
+	broken.Wait()
+	if broken.IsConnected() {
+		t.Error("client still connected after its handler panicked")
+	}
+
+	// 其他会话不受影响
+	if err := healthy.Send(&events.Event{Type: events.RealtimeClientEventResponseCreate}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-healthyDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("healthy session did not receive response.done")
+	}
+}
+
+func TestRecoverReadLoopPanic(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	errs := make(chan error, 4)
+	c := NewRealtimeClient(server.URL, "", nil, WithEventDump(panicWriter{}), WithOnInternalError(func(err error) {
+		errs <- err
+		panic("handler panics too")
+	}))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect()
+	var panicErr *PanicError
+	if err := waitInternalError(t, errs); !errors.As(err, &panicErr) || panicErr.Value != "dump writer broken" {
+		t.Fatalf("internal error = %v, want dump writer panic", err)
+	}
+	c.Wait()
+	if c.IsConnected() {
+		t.Error("client still connected after the read loop panicked")
+	}
+}
+
+func TestInternalErrorOnServerDrop(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	errs := make(chan error, 4)
+	created := make(chan struct{}, 4)
+	c := NewRealtimeClient(server.URL, "", func(event *events.Event) error {
+		created <- struct{}{}
+		return nil
+	}, WithOnInternalError(func(err error) { errs <- err }))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	<-created
+	server.CloseConnections()
+	if err := waitInternalError(t, errs); !strings.Contains(err.Error(), "read message") {
+		t.Errorf("internal error = %v, want read failure", err)
+	}
+
+	// 主动断开不是内部错误
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	<-created
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait()
+	select {
+	case err := <-errs:
+		t.Errorf("unexpected internal error after Disconnect: %v", err)
+	default:
+	}
+}
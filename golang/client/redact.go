@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// RedactAction is what a RedactRule does with a matching field.
+type RedactAction int
+
+const (
+	// RedactSize replaces a base64 payload by its decoded size, "<9600 bytes>".
+	RedactSize RedactAction = iota
+	// RedactMask replaces the value by "<redacted>".
+	RedactMask
+	// RedactRemove removes the field.
+	RedactRemove
+)
+
+// RedactRule matches a JSON field by name at any depth of an event, for
+// example "audio" also matches session.beta_fields.tts_cloned.audio.
+type RedactRule struct {
+	Field  string
+	Types  []events.EventType // event types the rule applies to, empty for all
+	Action RedactAction
+}
+
+func (rule RedactRule) matches(field string, eventType events.EventType) bool {
+	return rule.Field == field && (len(rule.Types) == 0 || slices.Contains(rule.Types, eventType))
+}
+
+// DefaultRedactRules returns the rules used by the event dump: audio and
+// video payloads are replaced by their size. Append to the result to redact
+// more, e.g. instructions or function call arguments.
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{Field: "audio", Action: RedactSize},
+		{Field: "video_frame", Action: RedactSize},
+		// "delta" is only a payload for audio deltas, transcript deltas are kept.
+		{Field: "delta", Types: []events.EventType{events.RealtimeServerEventResponseAudioDelta}, Action: RedactSize},
+	}
+}
+
+// redact applies rules to the decoded event v in place. Empty strings are
+// left alone so absent payloads are not reported as "<0 bytes>".
+func redact(v any, eventType events.EventType, rules []RedactRule) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			i := slices.IndexFunc(rules, func(rule RedactRule) bool { return rule.matches(key, eventType) })
+			if i < 0 || value == "" || value == nil {
+				redact(value, eventType, rules)
+				continue
+			}
+			switch s, ok := value.(string); {
+			case rules[i].Action == RedactRemove:
+				delete(v, key)
+			case rules[i].Action == RedactSize && ok:
+				v[key] = fmt.Sprintf("<%d bytes>", base64DecodedLen(s))
+			default:
+				v[key] = "<redacted>"
+			}
+		}
+	case []any:
+		for _, value := range v {
+			redact(value, eventType, rules)
+		}
+	}
+}
+
+// base64DecodedLen returns the size of the data encoded in the padded base64 string s.
+func base64DecodedLen(s string) int {
+	n := len(s) / 4 * 3
+	for i := len(s) - 1; i >= 0 && i >= len(s)-2 && s[i] == '='; i-- {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+func TestPercentile(t *testing.T) {
+	var l latencies
+	for i := 10; i >= 1; i-- {
+		l = append(l, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[float64]time.Duration{0: 1, 50: 5, 90: 9, 99: 10, 100: 10} {
+		if got := l.percentile(p); got != want*time.Millisecond {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want*time.Millisecond)
+		}
+	}
+	if got := latencies(nil).percentile(50); got != 0 {
+		t.Errorf("empty percentile = %v, want 0", got)
+	}
+}
+
+func loadScript(t *testing.T, name string) *script.Script {
+	t.Helper()
+	s, err := script.LoadFile("../../samples/files/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRunMock(t *testing.T) {
+	frames, err := loadFrames("../../samples/files/pics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config{Script: loadScript(t, "Audio.ClientVad.Input"), Sessions: 4, Iterations: 2,
+		TurnTimeout: 5 * time.Second, Frames: frames}
+	r := run(context.Background(), cfg)
+	if r.Sessions != 4 || r.Turns != 8 || r.FailedTurns != 0 || r.Reconnects != 0 {
+		t.Fatalf("got %d sessions, %d turns, %d failed, %d reconnects, want 4, 8, 0, 0 (errors %v)",
+			r.Sessions, r.Turns, r.FailedTurns, r.Reconnects, r.Errors)
+	}
+	for _, m := range metrics {
+		want := 8
+		if m == metricConnect {
+			want = 4
+		}
+		if n := len(r.Latencies[m]); n != want {
+			t.Errorf("%s has %d samples, want %d", m, n, want)
+		}
+	}
+	var out bytes.Buffer
+	r.print(&out)
+	if !strings.Contains(out.String(), "error rate 0.0%") {
+		t.Errorf("report:\n%s", out.String())
+	}
+}
+
+func TestRunReconnects(t *testing.T) {
+	// 损坏的 response.done 使客户端断开连接
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventResponseDone}, Malformed: 1,
+	}))
+	defer server.Close()
+	cfg := &config{URL: server.URL, Script: loadScript(t, "Audio.ClientVad.Input"), Sessions: 1, Iterations: 3,
+		TurnTimeout: 5 * time.Second, MaxReconnects: 1}
+	r := run(context.Background(), cfg)
+	if r.Turns != 2 || r.FailedTurns != 2 || r.Errors["dropped"] != 2 || r.Reconnects != 1 {
+		t.Errorf("got %d turns, %d failed (%v), %d reconnects, want 2, 2 dropped, 1", r.Turns, r.FailedTurns, r.Errors, r.Reconnects)
+	}
+	if got := r.ErrorRate(); got != 1 {
+		t.Errorf("error rate = %v, want 1", got)
+	}
+}
+
+func TestRunServerErrors(t *testing.T) {
+	server := mockserver.New(mockserver.WithCapabilities("1", &events.Capabilities{InputAudioFormats: []string{"wav"}}))
+	defer server.Close()
+	cfg := &config{URL: server.URL, Script: loadScript(t, "Audio.ClientVad.Input"), Sessions: 2, Iterations: 1,
+		TurnTimeout: 5 * time.Second}
+	r := run(context.Background(), cfg)
+	if r.ConnectFailures != 2 || r.Turns != 0 || r.Errors["1214"] != 2 {
+		t.Errorf("got %d connect failures, %d turns (%v), want 2 setups rejected with 1214 and no turns", r.ConnectFailures, r.Turns, r.Errors)
+	}
+}
+
+func TestRunLongSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs for 35s")
+	}
+	// 会话持续时间超过客户端的 30s 等待时限后，读循环仍需继续处理事件，
+	// 否则之后的每一轮都会超时且不会触发重连
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Second)
+	defer cancel()
+	cfg := &config{Script: loadScript(t, "Audio.ClientVad.Input"), Sessions: 1, Iterations: 1 << 20,
+		Pace: 20 * time.Millisecond, TurnTimeout: 3 * time.Second, MaxReconnects: 3}
+	start := time.Now()
+	r := run(ctx, cfg)
+	if elapsed := time.Since(start); elapsed < 30*time.Second {
+		t.Fatalf("run returned after %v with %d turns (errors %v), want it to last until the deadline", elapsed, r.Turns, r.Errors)
+	}
+	if r.Turns == 0 || r.FailedTurns != 0 || r.Reconnects != 0 {
+		t.Errorf("got %d turns, %d failed (%v), %d reconnects, want all turns to succeed", r.Turns, r.FailedTurns, r.Errors, r.Reconnects)
+	}
+}
@@ -0,0 +1,141 @@
+// Command glm-loadtest runs N concurrent scripted sessions against a realtime
+// endpoint for capacity planning. Each session replays the pre-recorded turns
+// of an event script, in the samples/files/*.Input format, optionally with a
+// video frame per turn, and the command reports latency percentiles, error
+// rates and reconnect counts.
+//
+// Latencies are measured per turn from the trigger: the commit and
+// response.create events with client VAD, or the server's speech_stopped
+// event with server VAD.
+//
+// Without -url (or ZHIPU_REALTIME_URL) the sessions run against the
+// in-process mock server, which is useful to check the script and the
+// harness itself.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+func main() {
+	url := flag.String("url", os.Getenv("ZHIPU_REALTIME_URL"), "realtime endpoint, empty for the built-in mock server")
+	apiKey := flag.String("api-key", os.Getenv("ZHIPU_API_KEY"), "api key for -url")
+	mock := flag.Bool("mock", false, "use the built-in mock server even if -url is set")
+	scriptPath := flag.String("script", "samples/files/Audio.ClientVad.Input", "event script to replay")
+	sessions := flag.Int("sessions", 10, "number of concurrent sessions")
+	iterations := flag.Int("iterations", 1, "times each session replays the script turns")
+	ramp := flag.Duration("ramp", 0, "spread session starts over this duration")
+	pace := flag.Duration("pace", 135*time.Millisecond, "delay between input events, as in the samples")
+	turnTimeout := flag.Duration("turn-timeout", 30*time.Second, "wait this long for response.done after each turn")
+	maxReconnects := flag.Int("max-reconnects", 3, "reconnects per session after dropped connections")
+	framesDir := flag.String("frames", "", "directory of JPEG frames, one is appended to each turn")
+	timeout := flag.Duration("timeout", 0, "overall timeout, 0 for none")
+	verbose := flag.Bool("v", false, "log client activity")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	s, err := script.LoadFile(*scriptPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg := &config{URL: *url, APIKey: *apiKey, Script: s, Sessions: *sessions, Iterations: *iterations, Ramp: *ramp,
+		Pace: *pace, TurnTimeout: *turnTimeout, MaxReconnects: *maxReconnects}
+	if *mock {
+		cfg.URL, cfg.APIKey = "", ""
+	}
+	if *framesDir != "" {
+		if cfg.Frames, err = loadFrames(*framesDir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	target := cfg.URL
+	if target == "" {
+		target = "mock server"
+	}
+	fmt.Printf("running %d sessions x %d turns against %s\n\n", cfg.Sessions, cfg.Iterations*len(s.Turns), target)
+	r := run(ctx, cfg)
+	r.print(os.Stdout)
+	if r.Turns == 0 || r.FailedTurns > 0 || r.ConnectFailures > 0 {
+		os.Exit(1)
+	}
+}
+
+// run starts the sessions, waits for them to finish and returns the report.
+func run(ctx context.Context, cfg *config) *report {
+	if cfg.URL == "" {
+		server := mockserver.New()
+		defer server.Close()
+		cfg.URL = server.URL
+	}
+	r := newReport()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Sessions; i++ {
+		if i > 0 && cfg.Ramp > 0 {
+			select {
+			case <-time.After(cfg.Ramp / time.Duration(cfg.Sessions)):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newSession(cfg, r).run(ctx)
+		}()
+	}
+	wg.Wait()
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// loadFrames reads the JPEG files in dir in name order.
+func loadFrames(dir string) ([][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var frames [][]byte
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".jpg" && ext != ".jpeg") {
+			continue
+		}
+		frame, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no JPEG frames in %s", dir)
+	}
+	return frames, nil
+}
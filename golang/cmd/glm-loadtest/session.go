@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/client"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+// config is the load test configuration, filled in from the flags.
+type config struct {
+	URL, APIKey   string
+	Script        *script.Script
+	Sessions      int
+	Iterations    int           // times each session replays the script turns
+	Ramp          time.Duration // spread session starts over this duration
+	Pace          time.Duration // delay between input events
+	TurnTimeout   time.Duration // wait for response.done after the trigger
+	MaxReconnects int           // per session
+	Frames        [][]byte      // JPEG frames, one appended per turn before the trigger
+}
+
+var (
+	errTimeout = errors.New("turn timed out")
+	errDropped = errors.New("connection dropped")
+)
+
+// loadClient is the part of the client the load test uses.
+type loadClient interface {
+	client.RealtimeClient
+	IsConnected() bool
+}
+
+// turnState tracks the response to the turn in flight, or to the setup
+// events while connecting.
+type turnState struct {
+	trigger, created, firstAudio time.Time
+	serverVad                    bool
+	until                        events.EventType // server event that completes the turn
+	done                         chan error       // buffered, receives the result once
+}
+
+func newTurnState(until events.EventType) *turnState {
+	return &turnState{until: until, done: make(chan error, 1)}
+}
+
+func (t *turnState) finish(err error) {
+	select {
+	case t.done <- err:
+	default:
+	}
+}
+
+// session replays the script over one client connection, reconnecting when
+// the connection drops.
+type session struct {
+	cfg    *config
+	report *report
+	c      loadClient
+	frame  int // next entry of cfg.Frames
+
+	lock    sync.Mutex
+	turn    *turnState
+	dropped chan error
+}
+
+func newSession(cfg *config, r *report) *session {
+	s := &session{cfg: cfg, report: r, dropped: make(chan error, 1)}
+	s.c = client.NewRealtimeClient(cfg.URL, cfg.APIKey, s.onReceived, client.WithOnInternalError(s.onInternalError))
+	return s
+}
+
+func (s *session) onReceived(event *events.Event) error {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t := s.turn
+	if t == nil {
+		return nil
+	}
+	switch event.Type {
+	case events.RealtimeServerEventInputAudioBufferSpeechStopped:
+		if t.serverVad {
+			t.trigger = now
+		}
+	case events.RealtimeServerEventResponseCreated:
+		if t.created.IsZero() {
+			t.created = now
+		}
+	case events.RealtimeServerEventResponseAudioDelta:
+		if t.firstAudio.IsZero() {
+			t.firstAudio = now
+		}
+	case events.RealtimeServerEventSessionUpdated:
+		if t.until == event.Type {
+			t.finish(nil)
+		}
+	case events.RealtimeServerEventResponseDone:
+		if t.until != event.Type {
+			break
+		}
+		if event.Response != nil && event.Response.Status != "" && event.Response.Status != events.ResponseStatusCompleted {
+			t.finish(fmt.Errorf("response %s", event.Response.Status))
+		} else {
+			t.finish(nil)
+		}
+	case events.RealtimeServerEventError:
+		if err := client.NewServerError(event); err != nil {
+			t.finish(err)
+		} else {
+			t.finish(errors.New("error event without details"))
+		}
+	}
+	return nil
+}
+
+func (s *session) onInternalError(err error) {
+	select {
+	case s.dropped <- err:
+	default:
+	}
+}
+
+// run replays the script until it is done, ctx expires or the session runs
+// out of reconnects.
+func (s *session) run(ctx context.Context) {
+	connected, reconnects := s.connect(ctx) == nil, 0
+	defer func() {
+		_ = s.c.Disconnect()
+		s.report.session(reconnects)
+	}()
+	if !connected {
+		return
+	}
+	for i := 0; i < s.cfg.Iterations; i++ {
+		for _, turn := range s.cfg.Script.Turns {
+			if ctx.Err() != nil {
+				return
+			}
+			err := s.runTurn(ctx, turn)
+			if ctx.Err() != nil {
+				return // interrupted, not a failure of the endpoint
+			}
+			s.report.turn(cause(err))
+			if !errors.Is(err, errDropped) && s.c.IsConnected() {
+				continue
+			}
+			if !s.reconnect(ctx, &reconnects) {
+				return
+			}
+		}
+	}
+}
+
+// reconnect replaces a dropped connection within the reconnect budget.
+func (s *session) reconnect(ctx context.Context, reconnects *int) bool {
+	_ = s.c.Disconnect()
+	for *reconnects < s.cfg.MaxReconnects && ctx.Err() == nil {
+		*reconnects++
+		if s.connect(ctx) == nil {
+			return true
+		}
+		_ = s.c.Disconnect()
+	}
+	return false
+}
+
+// connect connects, sends the setup events and waits until the server
+// accepted them.
+func (s *session) connect(ctx context.Context) error {
+	select {
+	case <-s.dropped:
+	default:
+	}
+	start := time.Now()
+	if err := s.c.Connect(); err != nil {
+		s.report.connectFailed(cause(err))
+		return err
+	}
+	s.report.observe(metricConnect, time.Since(start))
+	if len(s.cfg.Script.Setup) == 0 {
+		return nil
+	}
+	t := newTurnState(events.RealtimeServerEventSessionUpdated)
+	defer s.track(t)()
+	for _, event := range s.cfg.Script.Setup {
+		if err := s.send(ctx, event); err != nil {
+			s.report.connectFailed(cause(err))
+			return err
+		}
+	}
+	if err := s.wait(ctx, t); err != nil {
+		s.report.connectFailed(cause(err))
+		return err
+	}
+	return nil
+}
+
+// track makes t the state updated by onReceived until the returned function
+// is called.
+func (s *session) track(t *turnState) func() {
+	s.lock.Lock()
+	s.turn = t
+	s.lock.Unlock()
+	return func() {
+		s.lock.Lock()
+		s.turn = nil
+		s.lock.Unlock()
+	}
+}
+
+// wait waits for t to complete within the turn timeout.
+func (s *session) wait(ctx context.Context, t *turnState) error {
+	timer := time.NewTimer(s.cfg.TurnTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-t.done:
+		return err
+	case err := <-s.dropped:
+		return fmt.Errorf("%w: %v", errDropped, err)
+	case <-timer.C:
+		return errTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *session) runTurn(ctx context.Context, turn script.Turn) error {
+	t := newTurnState(events.RealtimeServerEventResponseDone)
+	t.serverVad = len(turn.Trigger) == 0
+	defer s.track(t)()
+
+	for _, event := range turn.Input {
+		if err := s.send(ctx, event); err != nil {
+			return err
+		}
+	}
+	if len(s.cfg.Frames) > 0 {
+		frame := &events.Event{Type: events.RealtimeClientVideoAppend, VideoFrame: s.cfg.Frames[s.frame%len(s.cfg.Frames)]}
+		s.frame++
+		if err := s.send(ctx, frame); err != nil {
+			return err
+		}
+	}
+	// Trigger events are sent back to back so the pace does not count as
+	// server latency.
+	for _, event := range turn.Trigger {
+		if err := s.c.Send(event); err != nil {
+			return fmt.Errorf("%w: %v", errDropped, err)
+		}
+	}
+	s.lock.Lock()
+	if t.trigger.IsZero() {
+		t.trigger = time.Now()
+	}
+	s.lock.Unlock()
+
+	if err := s.wait(ctx, t); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.report.observe(metricDone, time.Since(t.trigger))
+	if !t.created.IsZero() {
+		s.report.observe(metricCreated, t.created.Sub(t.trigger))
+	}
+	if !t.firstAudio.IsZero() {
+		s.report.observe(metricFirstAudio, t.firstAudio.Sub(t.trigger))
+	}
+	return nil
+}
+
+// send sends an input event and waits for the pace.
+func (s *session) send(ctx context.Context, event *events.Event) error {
+	if err := s.c.Send(event); err != nil {
+		return fmt.Errorf("%w: %v", errDropped, err)
+	}
+	select {
+	case <-time.After(s.cfg.Pace):
+		return nil
+	case err := <-s.dropped:
+		return fmt.Errorf("%w: %v", errDropped, err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cause names the reason a turn failed for the report, "" on success.
+func cause(err error) string {
+	var serverErr *client.ServerError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &serverErr) && serverErr.EventError.Code != "":
+		return serverErr.EventError.Code
+	case errors.Is(err, errTimeout):
+		return "timeout"
+	case errors.Is(err, errDropped):
+		return "dropped"
+	default:
+		return err.Error()
+	}
+}
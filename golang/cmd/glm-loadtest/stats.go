@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencies is a set of samples of one metric.
+type latencies []time.Duration
+
+// percentile returns the nearest-rank p-th percentile, p in [0, 100].
+func (l latencies) percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(l)
+	slices.Sort(sorted)
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+// Metric names, in report order.
+const (
+	metricConnect    = "connect"
+	metricCreated    = "response.created"
+	metricFirstAudio = "first audio"
+	metricDone       = "response.done"
+)
+
+var metrics = []string{metricConnect, metricCreated, metricFirstAudio, metricDone}
+
+// precision of the reported latencies, fine enough for the mock server.
+const precision = 100 * time.Microsecond
+
+// report aggregates the results of all sessions. Its methods are safe for
+// concurrent use.
+type report struct {
+	lock sync.Mutex
+
+	Sessions, ConnectFailures int
+	Turns, FailedTurns        int
+	Reconnects                int
+	Errors                    map[string]int // failures by cause, e.g. a server error code or "timeout"
+	Latencies                 map[string]latencies
+	Elapsed                   time.Duration
+}
+
+func newReport() *report {
+	return &report{Errors: map[string]int{}, Latencies: map[string]latencies{}}
+}
+
+func (r *report) observe(metric string, d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Latencies[metric] = append(r.Latencies[metric], d)
+}
+
+func (r *report) turn(cause string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Turns++
+	if cause != "" {
+		r.FailedTurns++
+		r.Errors[cause]++
+	}
+}
+
+func (r *report) session(reconnects int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Sessions++
+	r.Reconnects += reconnects
+}
+
+func (r *report) connectFailed(cause string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ConnectFailures++
+	r.Errors[cause]++
+}
+
+// ErrorRate is the fraction of turns that failed.
+func (r *report) ErrorRate() float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.Turns == 0 {
+		return 0
+	}
+	return float64(r.FailedTurns) / float64(r.Turns)
+}
+
+func (r *report) print(w io.Writer) {
+	errorRate := r.ErrorRate()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	fmt.Fprintf(w, "elapsed:     %v\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "sessions:    %d (%d failed connection attempts)\n", r.Sessions, r.ConnectFailures)
+	fmt.Fprintf(w, "turns:       %d (%d failed, error rate %.1f%%)\n", r.Turns, r.FailedTurns, 100*errorRate)
+	fmt.Fprintf(w, "reconnects:  %d\n", r.Reconnects)
+	causes := make([]string, 0, len(r.Errors))
+	for cause := range r.Errors {
+		causes = append(causes, cause)
+	}
+	slices.Sort(causes)
+	for _, cause := range causes {
+		fmt.Fprintf(w, "  %-24s %d\n", cause+":", r.Errors[cause])
+	}
+	fmt.Fprintf(w, "\n%-18s %6s %10s %10s %10s %10s\n", "latency", "n", "p50", "p90", "p99", "max")
+	for _, m := range metrics {
+		l := r.Latencies[m]
+		fmt.Fprintf(w, "%-18s %6d %10v %10v %10v %10v\n", m, len(l),
+			l.percentile(50).Round(precision), l.percentile(90).Round(precision),
+			l.percentile(99).Round(precision), l.percentile(100).Round(precision))
+	}
+}
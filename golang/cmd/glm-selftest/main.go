@@ -0,0 +1,107 @@
+// Command glm-selftest runs the audio loopback self-test: it sends a known
+// tone through the full capture, encode, server, decode and playback chain and
+// reports timing and integrity.
+//
+// Without -url the test runs against an in-process mock server that echoes the
+// audio, which isolates the local audio stack. With -url (or
+// ZHIPU_REALTIME_URL) it runs against a real endpoint.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/diagnostics"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run is main without os.Exit, so deferred cleanup runs before exiting.
+func run() int {
+	url := flag.String("url", os.Getenv("ZHIPU_REALTIME_URL"), "realtime endpoint, empty for the built-in mock server")
+	apiKey := flag.String("api-key", os.Getenv("ZHIPU_API_KEY"), "api key for -url")
+	mock := flag.Bool("mock", false, "use the built-in mock server even if -url is set")
+	frequency := flag.Float64("freq", 440, "tone frequency in Hz")
+	duration := flag.Duration("duration", time.Second, "tone duration")
+	timeout := flag.Duration("timeout", 30*time.Second, "overall timeout")
+	maxConnect := flag.Duration("max-connect", 0, "fail if connecting takes longer, 0 for the default 5s, negative to disable")
+	maxFirstAudio := flag.Duration("max-first-audio", 0, "fail if the first response audio takes longer after the commit, 0 for the default 3s, negative to disable")
+	maxTotal := flag.Duration("max-total", 0, "fail if response.done takes longer after the commit, 0 for the default 10s, negative to disable")
+	wavPath := flag.String("wav", "", "write the response audio to this WAV file")
+	verbose := flag.Bool("v", false, "log client activity")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	cfg := diagnostics.LoopbackConfig{URL: *url, APIKey: *apiKey, Frequency: *frequency, Duration: *duration,
+		MaxConnectLatency: *maxConnect, MaxFirstAudioLatency: *maxFirstAudio, MaxTotalLatency: *maxTotal}
+	target := "real server"
+	if *mock || cfg.URL == "" {
+		// The mock echoes the input audio, which isolates the local audio stack.
+		server := mockserver.New()
+		defer server.Close()
+		cfg.URL, cfg.APIKey, cfg.Echo = server.URL, "", true
+		target = "mock server"
+	}
+	var wav *os.File
+	if *wavPath != "" {
+		var err error
+		if wav, err = os.Create(*wavPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer wav.Close()
+		cfg.Playback = wav
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report, err := diagnostics.RunLoopback(ctx, cfg)
+	if report != nil {
+		printReport(target, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "self-test could not run: %v\n", err)
+		return 2
+	}
+	if wav != nil {
+		if err := wav.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "write %s: %v\n", *wavPath, err)
+			return 2
+		}
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+func printReport(target string, r *diagnostics.LoopbackReport) {
+	fmt.Printf("target:              %s\n", target)
+	fmt.Printf("sent:                %d bytes (%v)\n", r.SentBytes, r.SentDuration)
+	fmt.Printf("received:            %d bytes (%v) in %d chunks\n", r.ReceivedBytes, r.ReceivedDuration, r.Chunks)
+	fmt.Printf("connect latency:     %v\n", r.ConnectLatency)
+	fmt.Printf("first audio latency: %v\n", r.FirstAudioLatency)
+	fmt.Printf("total latency:       %v\n", r.TotalLatency)
+	if r.Echo {
+		fmt.Printf("identical echo:      %v\n", r.Identical)
+		fmt.Printf("tone purity:         %.1f%%\n", 100*r.TonePurity)
+	}
+	if r.OK() {
+		fmt.Println("result:              PASS")
+		return
+	}
+	fmt.Println("result:              FAIL")
+	for _, p := range r.Problems {
+		fmt.Printf("  - %s\n", p)
+	}
+}
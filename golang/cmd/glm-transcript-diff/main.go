@@ -0,0 +1,105 @@
+// Command glm-transcript-diff replays the inputs of a recorded session
+// against a live or mock endpoint and diffs the resulting transcripts and
+// tool calls against the recorded baseline, to catch behavior regressions
+// after model or SDK upgrades.
+//
+// The inputs are an event script in the samples/files/*.Input format and the
+// baseline is a recording of the server events, one JSON event per line, as
+// written by the samples to *.Output or by this command with -update.
+//
+// Without -url (or ZHIPU_REALTIME_URL) the script is replayed against the
+// in-process mock server.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/transcript"
+)
+
+func main() {
+	url := flag.String("url", os.Getenv("ZHIPU_REALTIME_URL"), "realtime endpoint, empty for the built-in mock server")
+	apiKey := flag.String("api-key", os.Getenv("ZHIPU_API_KEY"), "api key for -url")
+	mock := flag.Bool("mock", false, "use the built-in mock server even if -url is set")
+	scriptPath := flag.String("script", "", "event script with the session inputs (required)")
+	baselinePath := flag.String("baseline", "", "recorded server events to compare against (required)")
+	update := flag.Bool("update", false, "write the replayed events to -baseline instead of comparing")
+	similarity := flag.Float64("similarity", 1, "minimum transcript similarity in [0, 1] after normalizing case, whitespace and punctuation")
+	pace := flag.Duration("pace", 135*time.Millisecond, "delay between input events, as in the samples")
+	turnTimeout := flag.Duration("turn-timeout", 30*time.Second, "wait this long for each response")
+	verbose := flag.Bool("v", false, "log client activity")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *scriptPath == "" || *baselinePath == "" || *similarity < 0 || *similarity > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	s, err := script.LoadFile(*scriptPath)
+	if err != nil {
+		fail(err)
+	}
+	var baseline *transcript.Transcript
+	if !*update {
+		if baseline, err = transcript.ReadFile(*baselinePath); err != nil {
+			fail(err)
+		}
+	}
+
+	cfg := transcript.ReplayConfig{URL: *url, APIKey: *apiKey, Pace: *pace, TurnTimeout: *turnTimeout}
+	if *mock || cfg.URL == "" {
+		server := mockserver.New()
+		defer server.Close()
+		cfg.URL, cfg.APIKey = server.URL, ""
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	evts, err := transcript.Replay(ctx, cfg, s)
+	if err != nil {
+		fail(err)
+	}
+
+	if *update {
+		var buf bytes.Buffer
+		if err := transcript.WriteEvents(&buf, evts); err != nil {
+			fail(err)
+		}
+		if err := os.WriteFile(*baselinePath, buf.Bytes(), 0644); err != nil {
+			fail(err)
+		}
+		fmt.Printf("recorded %d events to %s\n", len(evts), *baselinePath)
+		return
+	}
+	got := transcript.FromEvents(evts)
+	diffs := transcript.Diff(baseline, got, transcript.DiffOptions{MinSimilarity: similarity})
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	if len(diffs) > 0 {
+		fmt.Printf("%d differences in %d responses\n", len(diffs), len(got.Responses))
+		os.Exit(1)
+	}
+	fmt.Printf("no differences in %d responses\n", len(got.Responses))
+}
+
+// fail exits with status 2: the comparison could not run.
+func fail(err error) {
+	if errors.Is(err, transcript.ErrTurnTimeout) {
+		err = fmt.Errorf("%w (see -turn-timeout)", err)
+	}
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
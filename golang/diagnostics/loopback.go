@@ -0,0 +1,375 @@
+// Package diagnostics provides self-tests that help rule out local problems
+// during support escalations.
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/client"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/tools"
+)
+
+// LoopbackConfig configures RunLoopback. Zero fields use the defaults below.
+type LoopbackConfig struct {
+	// URL and APIKey of the realtime endpoint, URL is required.
+	URL, APIKey string
+	// Echo declares that the server echoes the input audio back, like the
+	// mock server started by cmd/glm-selftest. The response is then checked
+	// against the sent tone byte for byte, otherwise it is treated as speech.
+	Echo bool
+
+	Frequency     float64       // tone frequency in Hz, default 440
+	Amplitude     float64       // tone amplitude in (0, 1], default 0.5
+	Duration      time.Duration // tone duration, default 1s
+	ChunkDuration time.Duration // audio per input_audio_buffer.append, default 100ms
+	SampleRate    int           // PCM sample rate, default tools.DefaultSampleRate
+
+	// Latency limits, exceeding one is reported in Problems. Zero uses the
+	// default, a negative value disables the check.
+	MaxConnectLatency    time.Duration // default 5s
+	MaxFirstAudioLatency time.Duration // default 3s
+	MaxTotalLatency      time.Duration // default 10s
+
+	// Playback, if set, receives the response audio as a WAV file.
+	Playback io.Writer
+}
+
+const (
+	defaultFrequency     = 440
+	defaultAmplitude     = 0.5
+	defaultDuration      = time.Second
+	defaultChunkDuration = 100 * time.Millisecond
+
+	defaultMaxConnectLatency    = 5 * time.Second
+	defaultMaxFirstAudioLatency = 3 * time.Second
+	defaultMaxTotalLatency      = 10 * time.Second
+
+	// minTonePurity is the fraction of the echoed signal power that must be
+	// at the test frequency.
+	minTonePurity = 0.9
+)
+
+// LoopbackReport is the result of RunLoopback.
+type LoopbackReport struct {
+	Echo bool // whether the response was checked as an echo, see LoopbackConfig.Echo
+
+	SentBytes, ReceivedBytes       int
+	SentDuration, ReceivedDuration time.Duration
+	Chunks                         int // response.audio.delta events received
+
+	ConnectLatency    time.Duration // dial and handshake
+	FirstAudioLatency time.Duration // commit until the first audio delta
+	TotalLatency      time.Duration // commit until response.done
+
+	// TonePurity is the fraction of the received signal power at the test
+	// frequency, about 1 for an unaltered echo. Only checked with Echo.
+	TonePurity float64
+	// Identical reports whether the received audio equals the sent audio
+	// byte for byte. Only checked with Echo.
+	Identical bool
+
+	// Problems lists failed checks, empty if the chain is healthy.
+	Problems []string
+}
+
+// OK reports whether every check passed.
+func (r *LoopbackReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *LoopbackReport) problemf(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// RunLoopback generates a tone, runs it through the capture (float to PCM),
+// encode (base64 events), server, decode and playback (PCM to WAV) chain and
+// verifies timing and integrity. With cfg.Echo the response must be an exact
+// echo of the tone; against a real server the response is speech, so only its
+// presence, format and timing are checked.
+//
+// The error is non-nil if the test could not run, for example because the
+// connection failed or ctx expired; failed checks, latencies above the limits
+// and a connection lost during the test are reported in Problems.
+func RunLoopback(ctx context.Context, cfg LoopbackConfig) (*LoopbackReport, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("diagnostics: loopback url is empty")
+	}
+	cfg = withDefaults(cfg)
+	report := &LoopbackReport{Echo: cfg.Echo}
+
+	// Capture: the tone as the audio stack would deliver it.
+	samples := Tone(cfg.Frequency, cfg.Amplitude, cfg.SampleRate, cfg.Duration)
+	pcm := tools.Float32ToPcmS16(nil, samples)
+	report.SentBytes, report.SentDuration = len(pcm), pcmDuration(len(pcm), cfg.SampleRate)
+
+	var (
+		lock       sync.Mutex
+		received   []byte
+		firstAudio time.Time
+		serverErr  error
+		lostErr    error
+	)
+	ready, done := make(chan struct{}, 1), make(chan struct{}, 1)
+	signal := func(ch chan struct{}) {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	onReceived := func(event *events.Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		switch event.Type {
+		case events.RealtimeServerEventSessionUpdated:
+			signal(ready)
+		case events.RealtimeServerEventResponseAudioDelta:
+			chunk, err := base64.StdEncoding.DecodeString(event.Delta)
+			if err != nil {
+				serverErr = fmt.Errorf("decode audio delta: %w", err)
+				signal(done)
+				return nil
+			}
+			if firstAudio.IsZero() {
+				firstAudio = time.Now()
+			}
+			received = append(received, chunk...)
+			report.Chunks++
+		case events.RealtimeServerEventResponseDone:
+			signal(done)
+		case events.RealtimeServerEventError:
+			serverErr = client.NewServerError(event)
+			signal(done)
+			signal(ready)
+		}
+		return nil
+	}
+
+	// The client reports a dropped connection here; end the waits instead of
+	// running into the timeout.
+	onInternalError := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		lostErr = err
+		signal(ready)
+		signal(done)
+	}
+	connectionLost := func(waitingFor string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		if lostErr != nil {
+			report.problemf("connection lost while waiting for %s: %v", waitingFor, lostErr)
+		}
+		return lostErr != nil
+	}
+
+	c := client.NewRealtimeClient(cfg.URL, cfg.APIKey, onReceived, client.WithOnInternalError(onInternalError))
+	start := time.Now()
+	if err := c.Connect(); err != nil {
+		return report, fmt.Errorf("connect: %w", err)
+	}
+	defer c.Disconnect()
+	report.ConnectLatency = time.Since(start)
+	checkLatency(report, "connect", report.ConnectLatency, cfg.MaxConnectLatency)
+
+	// Client VAD with PCM in and out, as in the samples.
+	if err := c.Send(&events.Event{Type: events.RealtimeClientEventSessionUpdate, Session: &events.Session{
+		InputAudioFormat:  "pcm",
+		OutputAudioFormat: "pcm",
+		BetaFields:        &events.BetaFields{ChatMode: events.ChatModeAudio, TTSSource: "e2e"},
+	}}); err != nil {
+		return report, err
+	}
+	if err := wait(ctx, ready); err != nil {
+		return report, fmt.Errorf("waiting for session.updated: %w", err)
+	}
+	if connectionLost("session.updated") {
+		return report, nil
+	}
+
+	// Encode: stream the tone in real-time sized chunks.
+	chunkSize := 2 * int(int64(cfg.SampleRate)*int64(cfg.ChunkDuration)/int64(time.Second))
+	for rest := pcm; len(rest) > 0; {
+		n := min(chunkSize, len(rest))
+		if err := c.Send(&events.Event{Type: events.RealtimeClientEventInputAudioBufferAppend, Audio: base64.StdEncoding.EncodeToString(rest[:n])}); err != nil {
+			return report, err
+		}
+		rest = rest[n:]
+	}
+	committed := time.Now()
+	for _, eventType := range []events.EventType{events.RealtimeClientEventInputAudioBufferCommit, events.RealtimeClientEventResponseCreate} {
+		if err := c.Send(&events.Event{Type: eventType}); err != nil {
+			return report, err
+		}
+	}
+	if err := wait(ctx, done); err != nil {
+		return report, fmt.Errorf("waiting for response.done: %w", err)
+	}
+	report.TotalLatency = time.Since(committed)
+	if connectionLost("response.done") {
+		return report, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if serverErr != nil {
+		return report, serverErr
+	}
+	if !firstAudio.IsZero() {
+		report.FirstAudioLatency = firstAudio.Sub(committed)
+		checkLatency(report, "first audio", report.FirstAudioLatency, cfg.MaxFirstAudioLatency)
+	}
+	checkLatency(report, "total", report.TotalLatency, cfg.MaxTotalLatency)
+	report.ReceivedBytes, report.ReceivedDuration = len(received), pcmDuration(len(received), cfg.SampleRate)
+
+	// Playback: the response as the audio stack would play it.
+	if cfg.Playback != nil {
+		if err := tools.Pcm2WavStream(cfg.Playback, bytes.NewReader(received), int64(len(received)), tools.WithSampleRate(cfg.SampleRate)); err != nil {
+			return report, fmt.Errorf("write playback wav: %w", err)
+		}
+	}
+
+	verify(report, cfg, pcm, received)
+	return report, nil
+}
+
+func verify(report *LoopbackReport, cfg LoopbackConfig, sent, received []byte) {
+	if len(received) == 0 {
+		report.problemf("no response audio received")
+		return
+	}
+	if len(received)%2 != 0 {
+		report.problemf("response audio has odd length %d, not 16-bit PCM", len(received))
+	}
+	if rms(tools.PcmS16ToFloat32(nil, received)) < 1e-3 {
+		report.problemf("response audio is silent")
+	}
+	if !report.Echo {
+		return
+	}
+
+	report.Identical = bytes.Equal(sent, received)
+	if !report.Identical {
+		report.problemf("echoed audio differs from the sent tone (%d bytes sent, %d received)", len(sent), len(received))
+	}
+	if report.ReceivedDuration != report.SentDuration {
+		report.problemf("echoed duration %v, want %v", report.ReceivedDuration, report.SentDuration)
+	}
+	report.TonePurity = TonePurity(tools.PcmS16ToFloat32(nil, received), cfg.Frequency, cfg.SampleRate)
+	if report.TonePurity < minTonePurity {
+		report.problemf("only %.0f%% of the echoed power is at %g Hz", 100*report.TonePurity, cfg.Frequency)
+	}
+}
+
+func checkLatency(report *LoopbackReport, name string, latency, limit time.Duration) {
+	if limit > 0 && latency > limit {
+		report.problemf("%s latency %v exceeds %v", name, latency.Round(time.Millisecond), limit)
+	}
+}
+
+func withDefaults(cfg LoopbackConfig) LoopbackConfig {
+	if cfg.Frequency <= 0 {
+		cfg.Frequency = defaultFrequency
+	}
+	if cfg.Amplitude <= 0 || cfg.Amplitude > 1 {
+		cfg.Amplitude = defaultAmplitude
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultDuration
+	}
+	if cfg.ChunkDuration <= 0 {
+		cfg.ChunkDuration = defaultChunkDuration
+	}
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = tools.DefaultSampleRate
+	}
+	if cfg.MaxConnectLatency == 0 {
+		cfg.MaxConnectLatency = defaultMaxConnectLatency
+	}
+	if cfg.MaxFirstAudioLatency == 0 {
+		cfg.MaxFirstAudioLatency = defaultMaxFirstAudioLatency
+	}
+	if cfg.MaxTotalLatency == 0 {
+		cfg.MaxTotalLatency = defaultMaxTotalLatency
+	}
+	return cfg
+}
+
+func wait(ctx context.Context, ch <-chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var errNoSamples = errors.New("diagnostics: no samples")
+
+func pcmDuration(n, sampleRate int) time.Duration {
+	return time.Duration(n/2) * time.Second / time.Duration(sampleRate)
+}
+
+// Tone returns a sine wave of the given frequency, amplitude and duration.
+func Tone(frequency, amplitude float64, sampleRate int, duration time.Duration) []float32 {
+	n := int(int64(sampleRate) * int64(duration) / int64(time.Second))
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+	}
+	return samples
+}
+
+// TonePurity returns the fraction of the power of samples that is at the
+// given frequency, computed with the Goertzel algorithm. It is close to 1 for
+// a pure tone and close to 0 for silence, noise or another frequency.
+func TonePurity(samples []float32, frequency float64, sampleRate int) float64 {
+	power, err := goertzelPower(samples, frequency, sampleRate)
+	if err != nil {
+		return 0
+	}
+	total := 0.0
+	for _, s := range samples {
+		total += float64(s) * float64(s)
+	}
+	if total == 0 {
+		return 0
+	}
+	return math.Min(power/total, 1)
+}
+
+// goertzelPower returns the signal power at frequency, scaled so that a
+// pure tone yields the total power of samples.
+func goertzelPower(samples []float32, frequency float64, sampleRate int) (float64, error) {
+	n := len(samples)
+	if n == 0 {
+		return 0, errNoSamples
+	}
+	coeff := 2 * math.Cos(2*math.Pi*frequency/float64(sampleRate))
+	var s1, s2 float64
+	for _, x := range samples {
+		s0 := float64(x) + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	magnitude := s1*s1 + s2*s2 - coeff*s1*s2
+	return 2 * magnitude / float64(n), nil
+}
+
+func rms(samples []float32) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
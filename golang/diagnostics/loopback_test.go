@@ -0,0 +1,143 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/tools"
+)
+
+func TestTonePurity(t *testing.T) {
+	tone := Tone(440, 0.5, 24000, time.Second)
+	tests := []struct {
+		name    string
+		samples []float32
+		min     float64
+		max     float64
+	}{
+		{"pure tone", tone, 0.99, 1},
+		{"other frequency", Tone(1000, 0.5, 24000, time.Second), 0, 0.01},
+		{"silence", make([]float32, 24000), 0, 0},
+		{"empty", nil, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := TonePurity(tt.samples, 440, 24000); got < tt.min || got > tt.max || math.IsNaN(got) {
+			t.Errorf("%s: TonePurity() = %v, want in [%v, %v]", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRunLoopbackMock(t *testing.T) {
+	server := mockserver.New()
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var playback bytes.Buffer
+	report, err := RunLoopback(ctx, LoopbackConfig{URL: server.URL, Echo: true, Duration: 500 * time.Millisecond, Playback: &playback})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || !report.Echo || !report.Identical {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.SentDuration != 500*time.Millisecond || report.ReceivedBytes != report.SentBytes || report.Chunks == 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.TonePurity < 0.99 || report.FirstAudioLatency <= 0 || report.TotalLatency < report.FirstAudioLatency {
+		t.Errorf("report = %+v", report)
+	}
+
+	header, err := tools.ReadWavHeader(&playback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.SampleRate != tools.DefaultSampleRate || header.DataSize != int64(report.ReceivedBytes) {
+		t.Errorf("playback header = %+v", header)
+	}
+}
+
+func TestRunLoopbackNoAudio(t *testing.T) {
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventResponseAudioDelta}, Drop: 1,
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// 按真实服务端处理，只检查是否收到音频
+	report, err := RunLoopback(ctx, LoopbackConfig{URL: server.URL, Duration: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.Echo || len(report.Problems) != 1 {
+		t.Errorf("report = %+v, want a missing audio problem", report)
+	}
+}
+
+func TestRunLoopbackConnectError(t *testing.T) {
+	server := mockserver.New(mockserver.WithAPIKey("key"))
+	defer server.Close()
+	if _, err := RunLoopback(context.Background(), LoopbackConfig{URL: server.URL, APIKey: "wrong"}); err == nil {
+		t.Error("RunLoopback with a wrong api key succeeded")
+	}
+	if _, err := RunLoopback(context.Background(), LoopbackConfig{}); err == nil {
+		t.Error("RunLoopback without a url succeeded")
+	}
+}
+
+func TestRunLoopbackLatencyLimits(t *testing.T) {
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventResponseAudioDelta}, Delay: 300 * time.Millisecond,
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	report, err := RunLoopback(ctx, LoopbackConfig{URL: server.URL, Echo: true, Duration: 200 * time.Millisecond,
+		MaxFirstAudioLatency: 100 * time.Millisecond, MaxTotalLatency: 200 * time.Millisecond, MaxConnectLatency: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 回声本身完整，只有时延超限
+	if !report.Identical || len(report.Problems) != 2 ||
+		!strings.HasPrefix(report.Problems[0], "first audio latency") || !strings.HasPrefix(report.Problems[1], "total latency") {
+		t.Errorf("report = %+v, want first audio and total latency problems", report)
+	}
+
+	report, err = RunLoopback(ctx, LoopbackConfig{URL: server.URL, Echo: true, Duration: 200 * time.Millisecond,
+		MaxFirstAudioLatency: -1, MaxTotalLatency: -1})
+	if err != nil || !report.OK() {
+		t.Errorf("disabled limits: report = %+v, err = %v", report, err)
+	}
+}
+
+func TestRunLoopbackConnectionLost(t *testing.T) {
+	server := mockserver.New(mockserver.WithFaults(mockserver.Fault{
+		Types: []events.EventType{events.RealtimeServerEventResponseAudioDelta}, Delay: 5 * time.Second,
+	}))
+	defer server.Close()
+	go func() {
+		// 等到音频提交后再断开
+		for len(server.ReceivedOfType(events.RealtimeClientEventResponseCreate)) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		server.CloseConnections()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	report, err := RunLoopback(ctx, LoopbackConfig{URL: server.URL, Echo: true, Duration: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("RunLoopback took %v after the connection was lost", elapsed)
+	}
+	if len(report.Problems) != 1 || !strings.HasPrefix(report.Problems[0], "connection lost while waiting for response.done") {
+		t.Errorf("report = %+v, want a connection lost problem", report)
+	}
+}
@@ -156,11 +156,22 @@ type Session struct {
 	MaxResponseOutputTokens  any                      `json:"max_response_output_tokens,omitempty"` // "inf" or int
 	InputAudioNoiseReduction *NoiseReduction          `json:"input_audio_noise_reduction,omitempty"`
 	BetaFields               *BetaFields              `json:"beta_fields,omitempty"`
+	ProtocolVersion          string                   `json:"protocol_version,omitempty"` // 仅 session.created 返回
+	Capabilities             *Capabilities            `json:"capabilities,omitempty"`     // 仅 session.created 返回
 	// 这里是专门为了调式用的， 必须为指针，内部字段不暴露
 	FlowBackend *string `json:"flow_backend,omitempty"`
 	TTSBackend  *string `json:"tts_backend,omitempty"`
 }
 
+// Capabilities 为服务端在 session.created 中声明的能力，未声明的字段为零值
+type Capabilities struct {
+	InputAudioFormats  []string   `json:"input_audio_formats,omitempty"`
+	OutputAudioFormats []string   `json:"output_audio_formats,omitempty"`
+	ChatModes          []ChatMode `json:"chat_modes,omitempty"`
+	Video              *bool      `json:"video,omitempty"`
+	MaxFPS             int        `json:"max_fps,omitempty"`
+}
+
 type InputAudioTranscription struct {
 	Enabled bool   `json:"enabled"`
 	Model   string `json:"model"`
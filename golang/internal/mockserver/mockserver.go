@@ -0,0 +1,448 @@
+// Package mockserver provides an in-process imitation of the GLM realtime
+// websocket endpoint for tests and diagnostics that must run without
+// credentials or network access.
+//
+// The server implements the parts of the protocol the SDK relies on: session
+// creation and update, client and server VAD, function calls, video frames
+// and responses. Response audio echoes the committed input audio back, so
+// callers can verify the audio path end to end. Faults can be injected per
+// event type to exercise error handling.
+package mockserver
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/gorilla/websocket"
+)
+
+// Fault describes a fault injected into server events whose type is listed
+// in Types, or into every server event when Types is empty.
+type Fault struct {
+	Types []events.EventType
+	// Drop is the probability in [0, 1] that a matching event is not sent.
+	Drop float64
+	// Malformed is the probability in [0, 1] that a matching event is sent
+	// as invalid JSON.
+	Malformed float64
+	// Delay is added before every matching event is sent.
+	Delay time.Duration
+}
+
+func (f *Fault) matches(t events.EventType) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, ft := range f.Types {
+		if ft == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Server is a mock realtime server listening on a local port.
+type Server struct {
+	// URL is the websocket URL clients should dial.
+	URL string
+
+	srv        *httptest.Server
+	apiKey     string
+	transcript string
+	toolCall   *events.Item
+	vadLevel   float64
+	chunkSize  int
+	protocol   string
+	caps       *events.Capabilities
+	rateLimits []events.RateLimit
+
+	lock     sync.Mutex
+	rnd      *rand.Rand
+	faults   []Fault
+	conns    map[*conn]struct{}
+	received []*events.Event
+	nextID   int
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithAPIKey makes the server reject connections whose Authorization header
+// does not carry the given bearer token.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) { s.apiKey = apiKey }
+}
+
+// WithTranscript sets the transcript of every generated response.
+func WithTranscript(transcript string) Option {
+	return func(s *Server) { s.transcript = transcript }
+}
+
+// WithFunctionCall makes the first response of a session that declares tools
+// a call to the named function with the given JSON arguments.
+func WithFunctionCall(name, arguments string) Option {
+	return func(s *Server) {
+		s.toolCall = &events.Item{Type: events.ItemTypeFunctionCall, Name: name, Arguments: arguments}
+	}
+}
+
+// WithCapabilities makes session.created announce the given protocol version
+// and capabilities.
+func WithCapabilities(protocolVersion string, caps *events.Capabilities) Option {
+	return func(s *Server) { s.protocol, s.caps = protocolVersion, caps }
+}
+
+// WithRateLimits announces the given limits in x-ratelimit-* handshake
+// headers, and sends rate_limits.updated after every response with the
+// "requests" limit decremented. Limits are tracked per connection.
+func WithRateLimits(limits ...events.RateLimit) Option {
+	return func(s *Server) { s.rateLimits = limits }
+}
+
+// WithSeed seeds the random source used for fault injection.
+func WithSeed(seed int64) Option {
+	return func(s *Server) { s.rnd = rand.New(rand.NewSource(seed)) }
+}
+
+// WithFaults sets the faults injected from the start.
+func WithFaults(faults ...Fault) Option {
+	return func(s *Server) { s.faults = faults }
+}
+
+// New starts a mock server. Call Close when done.
+func New(opts ...Option) *Server {
+	s := &Server{
+		transcript: "mock response",
+		vadLevel:   0.02,
+		chunkSize:  4800, // 100ms of 24kHz 16-bit mono
+		rnd:        rand.New(rand.NewSource(1)),
+		conns:      map[*conn]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveWs))
+	s.URL = "ws" + strings.TrimPrefix(s.srv.URL, "http")
+	return s
+}
+
+// Close drops all connections and stops the server.
+func (s *Server) Close() {
+	s.CloseConnections()
+	s.srv.Close()
+}
+
+// SetFaults replaces the injected faults; it applies to events sent after
+// the call, including those on open connections.
+func (s *Server) SetFaults(faults ...Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = faults
+}
+
+// CloseConnections abruptly closes every open connection without a close
+// handshake, imitating a network failure.
+func (s *Server) CloseConnections() {
+	s.lock.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.lock.Unlock()
+	for _, c := range conns {
+		_ = c.ws.Close()
+	}
+}
+
+// Received returns the client events received so far, in arrival order.
+func (s *Server) Received() []*events.Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*events.Event(nil), s.received...)
+}
+
+// ReceivedOfType returns the received client events of the given type.
+func (s *Server) ReceivedOfType(t events.EventType) []*events.Event {
+	var out []*events.Event
+	for _, e := range s.Received() {
+		if e.Type == t {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (s *Server) id(prefix string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nextID++
+	return fmt.Sprintf("%s_%04d", prefix, s.nextID)
+}
+
+func (s *Server) serveWs(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		http.Error(w, `{"error":{"code":"1000","message":"invalid api key"}}`, http.StatusUnauthorized)
+		return
+	}
+	header := http.Header{}
+	for _, l := range s.rateLimits {
+		name := strings.ReplaceAll(l.Name, "_", "-")
+		header.Set("X-Ratelimit-Limit-"+name, strconv.Itoa(l.Limit))
+		header.Set("X-Ratelimit-Remaining-"+name, strconv.Itoa(l.Remaining))
+		header.Set("X-Ratelimit-Reset-"+name, time.Duration(l.ResetSeconds*float32(time.Second)).String())
+	}
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, header)
+	if err != nil {
+		return
+	}
+	c := &conn{server: s, ws: ws, rateLimits: slices.Clone(s.rateLimits), session: &events.Session{
+		ID:                s.id("sess"),
+		Object:            "realtime.session",
+		Model:             "mock-realtime",
+		Modalities:        events.DefaultModalities,
+		InputAudioFormat:  "pcm",
+		OutputAudioFormat: "pcm",
+		ProtocolVersion:   s.protocol,
+		Capabilities:      s.caps,
+	}}
+	s.lock.Lock()
+	s.conns[c] = struct{}{}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, c)
+		s.lock.Unlock()
+		_ = ws.Close()
+	}()
+	c.serve()
+}
+
+// conn is the state of one client session.
+type conn struct {
+	server  *Server
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	session    *events.Session
+	audio      []byte // uncommitted input audio
+	speaking   bool
+	toolCalled bool
+	rateLimits []events.RateLimit
+}
+
+func (c *conn) serve() {
+	c.send(&events.Event{Type: events.RealtimeServerEventSessionCreated, Session: c.session})
+	for {
+		_, message, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		event := &events.Event{}
+		if err := json.Unmarshal(message, event); err != nil {
+			c.sendError("invalid_request_error", "invalid_event", "malformed JSON")
+			continue
+		}
+		c.server.lock.Lock()
+		c.server.received = append(c.server.received, event)
+		c.server.lock.Unlock()
+		c.handle(event)
+	}
+}
+
+func (c *conn) handle(event *events.Event) {
+	switch event.Type {
+	case events.RealtimeClientEventSessionUpdate:
+		if event.Session != nil {
+			update := *event.Session
+			if caps := c.server.caps; caps != nil && update.InputAudioFormat != "" &&
+				len(caps.InputAudioFormats) > 0 && !slices.Contains(caps.InputAudioFormats, update.InputAudioFormat) {
+				c.sendError("invalid_request_error", "1214", "input_audio_format "+update.InputAudioFormat+" is not supported")
+				return
+			}
+			update.ID, update.Object, update.Model = c.session.ID, c.session.Object, c.session.Model
+			c.session = &update
+		}
+		c.send(&events.Event{Type: events.RealtimeServerEventSessionUpdated, Session: c.session})
+	case events.RealtimeClientEventInputAudioBufferAppend:
+		pcm, err := base64.StdEncoding.DecodeString(event.Audio)
+		if err != nil {
+			c.sendError("invalid_request_error", "invalid_audio", "audio is not valid base64")
+			return
+		}
+		c.audio = append(c.audio, pcm...)
+		if c.serverVad() {
+			c.detectSpeech(pcm)
+		}
+	case events.RealtimeClientVideoAppend:
+		// frames are only recorded, see Server.Received
+	case events.RealtimeClientEventInputAudioBufferCommit:
+		// As with the real service, client VAD responses wait for response.create.
+		c.commit()
+	case events.RealtimeClientEventInputAudioBufferClear:
+		c.audio = nil
+		c.send(&events.Event{Type: events.RealtimeServerEventInputAudioBufferCleared})
+	case events.RealtimeClientEventConversationItemCreate:
+		item := &events.Item{ID: c.server.id("item"), Object: events.ItemObjectRealTimeItem, Status: events.ItemStatusCompleted}
+		if event.Item != nil {
+			*item = *event.Item
+			item.ID, item.Object, item.Status = c.server.id("item"), events.ItemObjectRealTimeItem, events.ItemStatusCompleted
+		}
+		c.send(&events.Event{Type: events.RealtimeServerEventConversationItemCreated, Item: item})
+	case events.RealtimeClientEventResponseCreate:
+		c.respond()
+	case events.RealtimeClientEventResponseCancel:
+	default:
+		c.sendError("invalid_request_error", "invalid_event", fmt.Sprintf("unsupported event type %q", event.Type))
+	}
+}
+
+func (c *conn) serverVad() bool {
+	return c.session.TurnDetection != nil && c.session.TurnDetection.Type == "server_vad"
+}
+
+// detectSpeech runs an energy based VAD over one appended chunk.
+func (c *conn) detectSpeech(pcm []byte) {
+	loud := rms(pcm) >= c.server.vadLevel
+	switch {
+	case loud && !c.speaking:
+		c.speaking = true
+		c.send(&events.Event{Type: events.RealtimeServerEventInputAudioBufferSpeechStarted})
+	case !loud && c.speaking:
+		c.speaking = false
+		c.send(&events.Event{Type: events.RealtimeServerEventInputAudioBufferSpeechStopped})
+		c.commit()
+		c.respond()
+	}
+}
+
+func (c *conn) commit() {
+	itemID := c.server.id("item")
+	c.send(&events.Event{Type: events.RealtimeServerEventInputAudioBufferCommitted, ItemID: itemID})
+	c.send(&events.Event{Type: events.RealtimeServerEventConversationItemCreated, Item: &events.Item{
+		ID: itemID, Object: events.ItemObjectRealTimeItem, Type: events.ItemTypeMessage,
+		Status: events.ItemStatusCompleted, Role: events.ItemRoleUser,
+		Content: []events.Content{{Type: events.ContentTypeInputAudio}},
+	}})
+}
+
+func (c *conn) respond() {
+	response := &events.Response{ID: c.server.id("resp"), Object: events.ResponseObjectResponse, Status: events.ResponseStatusInProgress}
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseCreated, Response: response})
+
+	if call := c.server.toolCall; call != nil && len(c.session.Tools) > 0 && !c.toolCalled {
+		c.toolCalled = true
+		item := *call
+		item.ID, item.Object, item.Status, item.CallId = c.server.id("item"), events.ItemObjectRealTimeItem, events.ItemStatusCompleted, c.server.id("call")
+		c.send(&events.Event{Type: events.RealtimeServerEventResponseOutputItemAdded, ResponseID: response.ID, Item: &item})
+		c.send(&events.Event{Type: events.RealtimeServerEventResponseFunctionCallArgumentsDone, ResponseID: response.ID,
+			ItemID: item.ID, CallID: item.CallId, Name: item.Name, Arguments: item.Arguments})
+		c.send(&events.Event{Type: events.RealtimeServerEventResponseOutputItemDone, ResponseID: response.ID, Item: &item})
+		c.audio = nil
+		c.finish(response, []events.Item{item})
+		return
+	}
+
+	transcript := c.server.transcript
+	item := events.Item{ID: c.server.id("item"), Object: events.ItemObjectRealTimeItem, Type: events.ItemTypeMessage,
+		Status: events.ItemStatusInProgress, Role: events.ItemRoleAssistant}
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseOutputItemAdded, ResponseID: response.ID, Item: &item})
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseContentPartAdded, ResponseID: response.ID, ItemID: item.ID,
+		Part: &events.ContentPart{Type: events.ContentTypeAudio}})
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseAudioTranscriptDelta, ResponseID: response.ID, ItemID: item.ID, Delta: transcript})
+	for audio := c.audio; len(audio) > 0; {
+		n := min(len(audio), c.server.chunkSize)
+		c.send(&events.Event{Type: events.RealtimeServerEventResponseAudioDelta, ResponseID: response.ID, ItemID: item.ID,
+			Delta: base64.StdEncoding.EncodeToString(audio[:n])})
+		audio = audio[n:]
+	}
+	c.audio = nil
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseAudioDone, ResponseID: response.ID, ItemID: item.ID})
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseAudioTranscriptDone, ResponseID: response.ID, ItemID: item.ID, Transcript: &transcript})
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseContentPartDone, ResponseID: response.ID, ItemID: item.ID,
+		Part: &events.ContentPart{Type: events.ContentTypeAudio, Transcript: transcript}})
+	item.Status = events.ItemStatusCompleted
+	item.Content = []events.Content{{Type: events.ContentTypeAudio, Transcript: &transcript}}
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseOutputItemDone, ResponseID: response.ID, Item: &item})
+	c.finish(response, []events.Item{item})
+}
+
+func (c *conn) finish(response *events.Response, output []events.Item) {
+	done := *response
+	done.Status, done.Output = events.ResponseStatusCompleted, output
+	done.Usage = &events.Usage{TotalTokens: 2, InputTokens: 1, OutputTokens: 1}
+	c.send(&events.Event{Type: events.RealtimeServerEventResponseDone, Response: &done})
+	if len(c.rateLimits) > 0 {
+		for i := range c.rateLimits {
+			if c.rateLimits[i].Name == "requests" && c.rateLimits[i].Remaining > 0 {
+				c.rateLimits[i].Remaining--
+			}
+		}
+		c.send(&events.Event{Type: events.RealtimeServerEventRateLimitsUpdated, RateLimits: slices.Clone(c.rateLimits)})
+	}
+}
+
+func (c *conn) sendError(errType, code, message string) {
+	c.send(&events.Event{Type: events.RealtimeServerEventError, Error: &events.EventError{Type: errType, Code: code, Message: message}})
+}
+
+// send writes a server event after applying the configured faults.
+func (c *conn) send(event *events.Event) {
+	if event.EventID == "" {
+		event.EventID = c.server.id("event")
+	}
+	message := []byte(event.ToJson())
+
+	c.server.lock.Lock()
+	var delay time.Duration
+	drop := false
+	for i := range c.server.faults {
+		f := &c.server.faults[i]
+		if !f.matches(event.Type) {
+			continue
+		}
+		delay += f.Delay
+		if f.Drop > 0 && c.server.rnd.Float64() < f.Drop {
+			drop = true
+		}
+		if f.Malformed > 0 && c.server.rnd.Float64() < f.Malformed {
+			message = message[:len(message)/2]
+		}
+	}
+	c.server.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if drop {
+		return
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.ws.WriteMessage(websocket.TextMessage, message)
+}
+
+// rms returns the root mean square level of 16-bit little endian PCM in [0, 1].
+func rms(pcm []byte) float64 {
+	n := len(pcm) / 2
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(n))
+}
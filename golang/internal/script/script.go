@@ -0,0 +1,113 @@
+// Package script loads the JSON-lines event scripts used by the samples, see
+// samples/files/*.Input, and splits them into conversation turns so tools can
+// replay them turn by turn.
+package script
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+// maxLineSize bounds a single event line; video frames make lines large.
+const maxLineSize = 10 * 1024 * 1024
+
+// ErrEmpty is returned when a script contains no turns.
+var ErrEmpty = errors.New("script: no turns")
+
+// Script is a parsed event script.
+type Script struct {
+	// Setup holds the leading session.update events, sent once per
+	// connection before the first turn.
+	Setup []*events.Event
+	Turns []Turn
+}
+
+// Turn is one user turn: the input events followed by the events that ask
+// the server to respond.
+type Turn struct {
+	// Input holds audio, video and other events, sent at the script pace.
+	Input []*events.Event
+	// Trigger holds the input_audio_buffer.commit and response.create events
+	// that end the turn with client VAD. It is empty with server VAD, where
+	// the server decides when the user stopped speaking.
+	Trigger []*events.Event
+}
+
+// Load reads a script from r, see ReadEvents for the format.
+func Load(r io.Reader) (*Script, error) {
+	evts, err := ReadEvents(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Script{}
+	var turn Turn
+	for _, event := range evts {
+		switch {
+		case isTrigger(event.Type):
+			turn.Trigger = append(turn.Trigger, event)
+		case event.Type == events.RealtimeClientEventSessionUpdate && len(s.Turns) == 0 && len(turn.Input) == 0 && len(turn.Trigger) == 0:
+			s.Setup = append(s.Setup, event)
+		default:
+			if len(turn.Trigger) > 0 {
+				s.Turns, turn = append(s.Turns, turn), Turn{}
+			}
+			turn.Input = append(turn.Input, event)
+		}
+	}
+	if len(turn.Input) > 0 || len(turn.Trigger) > 0 {
+		s.Turns = append(s.Turns, turn)
+	}
+	if len(s.Turns) == 0 {
+		return nil, ErrEmpty
+	}
+	return s, nil
+}
+
+// ReadEvents reads one JSON event per line from r. Lines that do not start
+// with "{" are ignored, as they are by the samples, so the same reader works
+// for the *.Input scripts and the *.Output recordings.
+func ReadEvents(r io.Reader) ([]*events.Event, error) {
+	var evts []*events.Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !strings.HasPrefix(text, "{") {
+			continue
+		}
+		event := &events.Event{}
+		if err := json.Unmarshal([]byte(text), event); err != nil {
+			return nil, fmt.Errorf("script: line %d: %w", line, err)
+		}
+		evts = append(evts, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("script: %w", err)
+	}
+	return evts, nil
+}
+
+// LoadFile reads the script at path.
+func LoadFile(path string) (*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func isTrigger(t events.EventType) bool {
+	return t == events.RealtimeClientEventInputAudioBufferCommit || t == events.RealtimeClientEventResponseCreate
+}
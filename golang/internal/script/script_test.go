@@ -0,0 +1,87 @@
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+func types(evts []*events.Event) []events.EventType {
+	out := make([]events.EventType, 0, len(evts))
+	for _, e := range evts {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestLoadSplitsTurns(t *testing.T) {
+	input := `{"type":"session.update","session":{"input_audio_format":"pcm"}}
+
+{"type":"input_audio_buffer.append","audio":"AAAA"}
+
+{"type":"input_audio_buffer.append_video_frame","video_frame":"/9j/"}
+{"type":"input_audio_buffer.commit"}
+{"type":"response.create"}
+not an event
+{"type":"conversation.item.create","item":{"type":"function_call_output"}}
+{"type":"response.create"}
+{"type":"input_audio_buffer.append","audio":"AAAA"}
+`
+	s, err := Load(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := types(s.Setup); len(got) != 1 || got[0] != events.RealtimeClientEventSessionUpdate {
+		t.Errorf("setup = %v, want session.update", got)
+	}
+	want := []struct{ input, trigger int }{{2, 2}, {1, 1}, {1, 0}}
+	if len(s.Turns) != len(want) {
+		t.Fatalf("got %d turns, want %d", len(s.Turns), len(want))
+	}
+	for i, w := range want {
+		if got := s.Turns[i]; len(got.Input) != w.input || len(got.Trigger) != w.trigger {
+			t.Errorf("turn %d = %v / %v, want %d input and %d trigger events", i, types(got.Input), types(got.Trigger), w.input, w.trigger)
+		}
+	}
+	if frame := s.Turns[0].Input[1].VideoFrame; len(frame) != 3 {
+		t.Errorf("video frame = %x, want 3 decoded bytes", frame)
+	}
+}
+
+func TestLoadSamples(t *testing.T) {
+	tests := []struct {
+		file         string
+		turns        int
+		lastTriggers int
+	}{
+		{"Audio.ClientVad.Input", 1, 2},
+		{"Audio.ClientVad.FC.Input", 2, 1},
+		{"Audio.ServerVad.Input", 1, 0},
+		{"Video.ClientVad.Input", 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			s, err := LoadFile("../../samples/files/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Setup) != 1 || len(s.Turns) != tt.turns {
+				t.Fatalf("got %d setup events and %d turns, want 1 and %d", len(s.Setup), len(s.Turns), tt.turns)
+			}
+			if got := len(s.Turns[len(s.Turns)-1].Trigger); got != tt.lastTriggers {
+				t.Errorf("last turn has %d trigger events, want %d", got, tt.lastTriggers)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(strings.NewReader(`{"type":"session.update"}`)); !errors.Is(err, ErrEmpty) {
+		t.Errorf("setup only: err = %v, want ErrEmpty", err)
+	}
+	if _, err := Load(strings.NewReader("\n{\"type\":")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("malformed: err = %v, want line 2", err)
+	}
+}
@@ -0,0 +1,140 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// MinSimilarity is the similarity in [0, 1] from which two transcripts
+	// are considered equal, after case, whitespace and punctuation are
+	// normalized; 0 accepts any transcript. Nil means 1, an exact match;
+	// models rarely repeat a response word for word, so comparisons against
+	// live endpoints usually need a lower value.
+	MinSimilarity *float64
+}
+
+// Difference is one mismatch between the baseline and the replay.
+type Difference struct {
+	Response int    // index of the response, -1 for session level fields
+	Field    string // e.g. "transcript" or "tool_calls[0].arguments"
+	Want     string // baseline value
+	Got      string // replayed value
+}
+
+func (d Difference) String() string {
+	where := "session"
+	if d.Response >= 0 {
+		where = fmt.Sprintf("response %d", d.Response)
+	}
+	return fmt.Sprintf("%s: %s: want %q, got %q", where, d.Field, d.Want, d.Got)
+}
+
+// Diff compares the replayed transcript got against baseline. Tool call
+// names must match exactly and their arguments must be equal JSON values.
+func Diff(baseline, got *Transcript, opts DiffOptions) []Difference {
+	minSimilarity := 1.0
+	if opts.MinSimilarity != nil {
+		minSimilarity = *opts.MinSimilarity
+	}
+	var diffs []Difference
+	if w, g := strings.Join(baseline.Errors, ","), strings.Join(got.Errors, ","); w != g {
+		diffs = append(diffs, Difference{Response: -1, Field: "errors", Want: w, Got: g})
+	}
+	if len(baseline.Responses) != len(got.Responses) {
+		diffs = append(diffs, Difference{Response: -1, Field: "responses",
+			Want: fmt.Sprint(len(baseline.Responses)), Got: fmt.Sprint(len(got.Responses))})
+	}
+	for i := 0; i < min(len(baseline.Responses), len(got.Responses)); i++ {
+		diffs = append(diffs, diffResponse(i, baseline.Responses[i], got.Responses[i], minSimilarity)...)
+	}
+	return diffs
+}
+
+func diffResponse(i int, want, got Response, minSimilarity float64) []Difference {
+	var diffs []Difference
+	add := func(field, w, g string) {
+		diffs = append(diffs, Difference{Response: i, Field: field, Want: w, Got: g})
+	}
+	if want.Status != got.Status {
+		add("status", string(want.Status), string(got.Status))
+	}
+	if Similarity(want.Input, got.Input) < minSimilarity {
+		add("input", want.Input, got.Input)
+	}
+	if Similarity(want.Transcript, got.Transcript) < minSimilarity {
+		add("transcript", want.Transcript, got.Transcript)
+	}
+	if len(want.ToolCalls) != len(got.ToolCalls) {
+		add("tool_calls", fmt.Sprint(len(want.ToolCalls)), fmt.Sprint(len(got.ToolCalls)))
+	}
+	for j := 0; j < min(len(want.ToolCalls), len(got.ToolCalls)); j++ {
+		w, g := want.ToolCalls[j], got.ToolCalls[j]
+		if w.Name != g.Name {
+			add(fmt.Sprintf("tool_calls[%d].name", j), w.Name, g.Name)
+		}
+		if !equalJSON(w.Arguments, g.Arguments) {
+			add(fmt.Sprintf("tool_calls[%d].arguments", j), w.Arguments, g.Arguments)
+		}
+	}
+	return diffs
+}
+
+// Similarity returns 1 minus the edit distance between the normalized a and
+// b divided by the length of the longer one: 1 for equal texts, 0 for texts
+// with nothing in common.
+func Similarity(a, b string) float64 {
+	ra, rb := normalize(a), normalize(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+// normalize lowercases s and drops whitespace and punctuation, which speech
+// models vary freely.
+func normalize(s string) []rune {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		out = append(out, unicode.ToLower(r))
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func equalJSON(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
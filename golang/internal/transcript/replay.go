@@ -0,0 +1,156 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/client"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+const defaultTurnTimeout = 30 * time.Second
+
+// ErrTurnTimeout is returned by Replay when the server does not finish a
+// response within ReplayConfig.TurnTimeout.
+var ErrTurnTimeout = errors.New("transcript: turn timed out")
+
+// ReplayConfig configures Replay.
+type ReplayConfig struct {
+	URL, APIKey string
+	Pace        time.Duration // delay between input events
+	TurnTimeout time.Duration // wait for each response, default 30s
+}
+
+// Replay sends the script to the endpoint turn by turn, waiting for the
+// response to each turn before sending the next, and returns every server
+// event received. The call_id of function_call_output items that have none
+// is filled in from the last function call, as the samples do. A server
+// error ends the turn but not the replay; it shows up in the transcript.
+func Replay(ctx context.Context, cfg ReplayConfig, s *script.Script) ([]*events.Event, error) {
+	if cfg.TurnTimeout <= 0 {
+		cfg.TurnTimeout = defaultTurnTimeout
+	}
+	var (
+		lock   sync.Mutex
+		evts   []*events.Event
+		callID string
+		until  events.EventType
+	)
+	done, dropped := make(chan struct{}, 1), make(chan error, 1)
+	onReceived := func(event *events.Event) error {
+		lock.Lock()
+		defer lock.Unlock()
+		evts = append(evts, event)
+		if event.Type == events.RealtimeServerEventResponseFunctionCallArgumentsDone {
+			callID = event.CallID
+		}
+		if event.Type == until || event.Type == events.RealtimeServerEventError {
+			select {
+			case done <- struct{}{}:
+			default:
+			}
+		}
+		return nil
+	}
+	onInternalError := func(err error) {
+		select {
+		case dropped <- err:
+		default:
+		}
+	}
+	c := client.NewRealtimeClient(cfg.URL, cfg.APIKey, onReceived, client.WithOnInternalError(onInternalError))
+	if err := c.Connect(); err != nil {
+		return nil, fmt.Errorf("transcript: connect: %w", err)
+	}
+	defer c.Disconnect()
+
+	expect := func(t events.EventType) {
+		lock.Lock()
+		defer lock.Unlock()
+		until = t
+		select {
+		case <-done:
+		default:
+		}
+	}
+	wait := func() error {
+		timer := time.NewTimer(cfg.TurnTimeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return nil
+		case err := <-dropped:
+			return fmt.Errorf("transcript: connection lost: %w", err)
+		case <-timer.C:
+			return ErrTurnTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	send := func(event *events.Event) error {
+		if event.Type == events.RealtimeClientEventConversationItemCreate && event.Item != nil &&
+			event.Item.Type == events.ItemTypeFunctionCallOutput && event.Item.CallId == "" {
+			lock.Lock()
+			item := *event.Item
+			item.CallId = callID
+			lock.Unlock()
+			copied := *event
+			copied.Item = &item
+			event = &copied
+		}
+		if err := c.Send(event); err != nil {
+			return fmt.Errorf("transcript: send %s: %w", event.Type, err)
+		}
+		return nil
+	}
+	pace := func() error {
+		select {
+		case <-time.After(cfg.Pace):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	result := func() []*events.Event {
+		lock.Lock()
+		defer lock.Unlock()
+		return slices.Clone(evts)
+	}
+	if len(s.Setup) > 0 {
+		expect(events.RealtimeServerEventSessionUpdated)
+		for _, event := range s.Setup {
+			if err := send(event); err != nil {
+				return result(), err
+			}
+		}
+		if err := wait(); err != nil {
+			return result(), err
+		}
+	}
+	for _, turn := range s.Turns {
+		expect(events.RealtimeServerEventResponseDone)
+		for _, event := range turn.Input {
+			if err := send(event); err != nil {
+				return result(), err
+			}
+			if err := pace(); err != nil {
+				return result(), err
+			}
+		}
+		for _, event := range turn.Trigger {
+			if err := send(event); err != nil {
+				return result(), err
+			}
+		}
+		if err := wait(); err != nil {
+			return result(), err
+		}
+	}
+	return result(), nil
+}
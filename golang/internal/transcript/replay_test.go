@@ -0,0 +1,91 @@
+package transcript
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/mockserver"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+func replay(t *testing.T, file string, opts ...mockserver.Option) *Transcript {
+	t.Helper()
+	s, err := script.LoadFile("../../samples/files/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := mockserver.New(opts...)
+	defer server.Close()
+	evts, err := Replay(context.Background(), ReplayConfig{URL: server.URL, TurnTimeout: 5 * time.Second}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FromEvents(evts)
+}
+
+func TestReplayDetectsRegression(t *testing.T) {
+	baseline := replay(t, "Audio.ClientVad.Input", mockserver.WithTranscript("今天天气晴朗"))
+	if len(baseline.Responses) != 1 || baseline.Responses[0].Transcript != "今天天气晴朗" {
+		t.Fatalf("baseline = %+v", baseline)
+	}
+	if diffs := Diff(baseline, replay(t, "Audio.ClientVad.Input", mockserver.WithTranscript("今天天气晴朗")), DiffOptions{}); len(diffs) != 0 {
+		t.Errorf("unchanged replay differs: %v", diffs)
+	}
+	diffs := Diff(baseline, replay(t, "Audio.ClientVad.Input", mockserver.WithTranscript("今天有雨")), DiffOptions{})
+	if len(diffs) != 1 || diffs[0].Field != "transcript" {
+		t.Errorf("differences = %v, want the transcript", diffs)
+	}
+}
+
+func TestReplayFunctionCall(t *testing.T) {
+	got := replay(t, "Audio.ClientVad.FC.Input", mockserver.WithFunctionCall("search_engine", `{"q":"天气"}`))
+	if len(got.Responses) != 2 {
+		t.Fatalf("got %d responses, want the function call and the answer", len(got.Responses))
+	}
+	if calls := got.Responses[0].ToolCalls; len(calls) != 1 || calls[0].Name != "search_engine" {
+		t.Errorf("tool calls = %+v, want search_engine", calls)
+	}
+	baseline := &Transcript{Responses: []Response{
+		{ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"北京天气"}`}}, Status: got.Responses[0].Status},
+		got.Responses[1],
+	}}
+	if diffs := Diff(baseline, got, DiffOptions{}); len(diffs) != 1 || diffs[0].Field != "tool_calls[0].arguments" {
+		t.Errorf("differences = %v, want the tool call arguments", diffs)
+	}
+}
+
+// 回放超过客户端 30s 等待时间的会话仍应得到完整转录
+func TestReplayLongSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("replays for more than 30s")
+	}
+	s, err := script.LoadFile("../../samples/files/Audio.ClientVad.Input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pace = 100 * time.Millisecond
+	turn := s.Turns[0]
+	n := int(32*time.Second/(time.Duration(len(turn.Input))*pace)) + 1
+	s.Turns = nil
+	for i := 0; i < n; i++ {
+		s.Turns = append(s.Turns, turn)
+	}
+
+	server := mockserver.New(mockserver.WithTranscript("今天天气晴朗"))
+	defer server.Close()
+	evts, err := Replay(context.Background(), ReplayConfig{URL: server.URL, Pace: pace, TurnTimeout: 3 * time.Second}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := FromEvents(evts)
+	if len(got.Responses) != n {
+		t.Fatalf("got %d responses, want %d", len(got.Responses), n)
+	}
+	for i, r := range got.Responses {
+		if r.Transcript != "今天天气晴朗" || r.Status != events.ResponseStatusCompleted {
+			t.Errorf("response %d = %+v", i, r)
+		}
+	}
+}
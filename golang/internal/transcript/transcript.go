@@ -0,0 +1,147 @@
+// Package transcript extracts what the server said and did in a session,
+// the response transcripts and tool calls, from the server events, and
+// compares it against a recorded baseline to catch behavior regressions
+// after model or SDK upgrades.
+package transcript
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+	"github.com/MetaGLM/glm-realtime-sdk/golang/internal/script"
+)
+
+// Transcript is the observable behavior of one session.
+type Transcript struct {
+	Responses []Response
+	// Errors holds the codes of the error events, in order.
+	Errors []string
+}
+
+// Response is one server response.
+type Response struct {
+	// Input is the transcription of the user audio committed before the
+	// response, empty unless input audio transcription is enabled.
+	Input string
+	// Transcript is the audio transcript, or the text of text responses.
+	Transcript string
+	ToolCalls  []ToolCall
+	Status     events.ResponseStatus
+}
+
+// ToolCall is a function call requested by the server.
+type ToolCall struct {
+	Name      string
+	Arguments string
+}
+
+// FromEvents builds the transcript of a session from its server events.
+// Transcript deltas are used for responses whose done events are missing.
+func FromEvents(evts []*events.Event) *Transcript {
+	t := &Transcript{}
+	var (
+		current *Response
+		input   string
+		deltas  strings.Builder
+		parts   []string
+	)
+	finish := func(status events.ResponseStatus) {
+		if current == nil {
+			return
+		}
+		if len(parts) == 0 && deltas.Len() > 0 {
+			parts = append(parts, deltas.String())
+		}
+		current.Transcript, current.Status = strings.Join(parts, ""), status
+		t.Responses = append(t.Responses, *current)
+		current, parts = nil, nil
+		deltas.Reset()
+	}
+	start := func() {
+		if current == nil {
+			current, input = &Response{Input: input}, ""
+		}
+	}
+	for _, e := range evts {
+		switch e.Type {
+		case events.RealtimeServerEventConversationItemInputAudioTranscriptionCompleted:
+			if e.Transcript != nil {
+				input += *e.Transcript
+			}
+		case events.RealtimeServerEventResponseCreated:
+			finish("")
+			start()
+		case events.RealtimeServerEventResponseAudioTranscriptDelta, events.RealtimeServerEventResponseTextDelta:
+			start()
+			deltas.WriteString(e.Delta)
+		case events.RealtimeServerEventResponseAudioTranscriptDone:
+			start()
+			if e.Transcript != nil {
+				parts = append(parts, *e.Transcript)
+			}
+		case events.RealtimeServerEventResponseTextDone:
+			start()
+			if e.Text != nil {
+				parts = append(parts, *e.Text)
+			}
+		case events.RealtimeServerEventResponseFunctionCallArgumentsDone:
+			start()
+			current.ToolCalls = append(current.ToolCalls, ToolCall{Name: e.Name, Arguments: e.Arguments})
+		case events.RealtimeServerEventResponseDone:
+			start()
+			var status events.ResponseStatus
+			if e.Response != nil {
+				status = e.Response.Status
+			}
+			finish(status)
+		case events.RealtimeServerEventError:
+			code := "unknown"
+			if e.Error != nil && e.Error.Code != "" {
+				code = e.Error.Code
+			}
+			t.Errors = append(t.Errors, code)
+		}
+	}
+	finish("")
+	return t
+}
+
+// Read builds a transcript from a recording of server events, one JSON
+// event per line as written by WriteEvents and the samples.
+func Read(r io.Reader) (*Transcript, error) {
+	evts, err := script.ReadEvents(r)
+	if err != nil {
+		return nil, err
+	}
+	return FromEvents(evts), nil
+}
+
+// ReadFile reads the recording at path, see Read.
+func ReadFile(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// WriteEvents writes evts one JSON event per line, readable by Read. Audio
+// payloads are left out to keep recordings small.
+func WriteEvents(w io.Writer, evts []*events.Event) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range evts {
+		if e.Type == events.RealtimeServerEventResponseAudioDelta {
+			elided := *e
+			elided.Delta = ""
+			e = &elided
+		}
+		if _, err := bw.WriteString(e.ToJson() + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
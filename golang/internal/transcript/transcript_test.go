@@ -0,0 +1,129 @@
+package transcript
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MetaGLM/glm-realtime-sdk/golang/events"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestFromEvents(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.RealtimeServerEventSessionCreated},
+		{Type: events.RealtimeServerEventConversationItemInputAudioTranscriptionCompleted, Transcript: ptr("今天天气")},
+		{Type: events.RealtimeServerEventResponseCreated},
+		{Type: events.RealtimeServerEventResponseFunctionCallArgumentsDone, Name: "search_engine", Arguments: `{"q":"天气"}`},
+		{Type: events.RealtimeServerEventResponseDone, Response: &events.Response{Status: events.ResponseStatusCompleted}},
+		{Type: events.RealtimeServerEventResponseCreated},
+		{Type: events.RealtimeServerEventResponseAudioTranscriptDelta, Delta: "晴"},
+		{Type: events.RealtimeServerEventResponseAudioTranscriptDone, Transcript: ptr("晴天。")},
+		{Type: events.RealtimeServerEventResponseDone, Response: &events.Response{Status: events.ResponseStatusCompleted}},
+		{Type: events.RealtimeServerEventError, Error: &events.EventError{Code: "rate_limit_exceeded"}},
+		// 缺少 done 事件时使用增量拼接
+		{Type: events.RealtimeServerEventResponseCreated},
+		{Type: events.RealtimeServerEventResponseTextDelta, Delta: "好"},
+		{Type: events.RealtimeServerEventResponseTextDelta, Delta: "的"},
+	}
+	want := &Transcript{
+		Responses: []Response{
+			{Input: "今天天气", ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"天气"}`}}, Status: events.ResponseStatusCompleted},
+			{Transcript: "晴天。", Status: events.ResponseStatusCompleted},
+			{Transcript: "好的"},
+		},
+		Errors: []string{"rate_limit_exceeded"},
+	}
+	if got := FromEvents(evts); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromEvents() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteEventsRoundTrip(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.RealtimeServerEventResponseCreated},
+		{Type: events.RealtimeServerEventResponseAudioDelta, Delta: strings.Repeat("A", 4096)},
+		{Type: events.RealtimeServerEventResponseAudioTranscriptDone, Transcript: ptr("你好")},
+		{Type: events.RealtimeServerEventResponseDone, Response: &events.Response{Status: events.ResponseStatusCompleted}},
+	}
+	var buf bytes.Buffer
+	if err := WriteEvents(&buf, evts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "AAAA") {
+		t.Error("audio payload was recorded")
+	}
+	if evts[1].Delta == "" {
+		t.Error("WriteEvents modified its input")
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := Diff(FromEvents(evts), got, DiffOptions{}); len(diffs) != 0 {
+		t.Errorf("round trip differs: %v", diffs)
+	}
+}
+
+func similarity(v float64) *float64 { return &v }
+
+func TestDiff(t *testing.T) {
+	baseline := &Transcript{Responses: []Response{
+		{Transcript: "今天北京晴，最高气温二十五度。", ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"天气","n":1}`}}},
+	}}
+	tests := []struct {
+		name          string
+		got           Response
+		minSimilarity *float64 // nil for the default
+		want          []string
+	}{
+		{"punctuation and argument order", Response{Transcript: "今天北京晴 最高气温二十五度",
+			ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"n":1, "q":"天气"}`}}}, nil, nil},
+		{"changed transcript", Response{Transcript: "今天北京多云，最高气温二十五度。",
+			ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"天气","n":1}`}}}, nil, []string{"transcript"}},
+		{"similar transcript", Response{Transcript: "今天北京多云，最高气温二十五度。",
+			ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"天气","n":1}`}}}, similarity(0.8), nil},
+		{"changed tool call", Response{Transcript: "今天北京晴，最高气温二十五度。",
+			ToolCalls: []ToolCall{{Name: "web_search", Arguments: `{"q":"天气"}`}}}, nil, []string{"tool_calls[0].name", "tool_calls[0].arguments"}},
+		{"any transcript", Response{Transcript: "明天有雨。",
+			ToolCalls: []ToolCall{{Name: "search_engine", Arguments: `{"q":"天气","n":1}`}}}, similarity(0), nil},
+		{"missing tool call", Response{Transcript: "今天北京晴，最高气温二十五度。"}, nil, []string{"tool_calls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, d := range Diff(baseline, &Transcript{Responses: []Response{tt.got}}, DiffOptions{MinSimilarity: tt.minSimilarity}) {
+				fields = append(fields, d.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("differences in %v, want %v", fields, tt.want)
+			}
+		})
+	}
+
+	diffs := Diff(baseline, &Transcript{Errors: []string{"internal_error"}}, DiffOptions{})
+	if len(diffs) != 2 || diffs[0].String() != `session: errors: want "", got "internal_error"` ||
+		diffs[1].String() != `session: responses: want "1", got "0"` {
+		t.Errorf("session differences = %v", diffs)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"Hello, world!", "hello world", 1},
+		{"abcd", "abce", 0.75},
+		{"abc", "", 0},
+		{"你好", "您好", 0.5},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -57,7 +57,7 @@ func doTestRealtimeClient(inputFilePath, outputFilePath string) {
 				log.Fatalf("Error decoding audio: %v\n", err)
 				return err
 			}
-			bytes, err = tools.Pcm2Wav(bytes, 24000, 1, 16)
+			bytes, err = tools.Pcm2Wav(bytes, tools.WithSampleRate(24000), tools.WithNumChannels(1), tools.WithBitDepth(16))
 			if err != nil || len(bytes) == 0 {
 				log.Fatalf("Error converting pcm to wav: %v\n", err)
 				return err
@@ -163,7 +163,7 @@ func doTestRealtimeClientWithFC(inputFilePath, outputFilePath string) {
 				log.Fatalf("Error decoding audio: %v\n", err)
 				return err
 			}
-			bytes, err = tools.Pcm2Wav(bytes, 24000, 1, 16)
+			bytes, err = tools.Pcm2Wav(bytes, tools.WithSampleRate(24000), tools.WithNumChannels(1), tools.WithBitDepth(16))
 			if err != nil || len(bytes) == 0 {
 				log.Fatalf("Error converting pcm to wav: %v\n", err)
 				return err
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// MediaBackend 抽象 tools 包依赖的媒体处理能力，默认实现为调用 ffmpeg/ffprobe 可执行文件的 FFmpegBackend，
+// 部署方可以换成基于 libav 绑定或纯 Go 的实现，通过 SetDefaultBackend 或 WithBackend 切换，调用方代码无需修改
+//
+// 各方法在 ctx 被取消后应尽快返回 ctx.Err()；不支持的操作返回包装自 ErrUnsupported 的错误
+type MediaBackend interface {
+	// DecodeAudio 将 r 中任意封装与编码的音频解码为 16 位小端 PCM，按 params 重采样后写入 w
+	DecodeAudio(ctx context.Context, w io.Writer, r io.Reader, params AudioParams) error
+	// ExtractFrames 从 r 中的 H.264 Annex B 码流抽帧，每解码出一张 JPEG 就调用一次 onFrame，
+	// onFrame 返回错误时停止并返回该错误；传给 onFrame 的切片在回调返回后仍可安全持有
+	ExtractFrames(ctx context.Context, r io.Reader, params FrameParams, onFrame func(frame []byte) error) error
+	// Probe 读取 r 中媒体的封装格式、时长与各条流的参数
+	Probe(ctx context.Context, r io.Reader) (*MediaInfo, error)
+	// Transcode 将 r 中的媒体按 params 转换后写入 w
+	Transcode(ctx context.Context, w io.Writer, r io.Reader, params TranscodeParams) error
+}
+
+// AudioParams 为解码输出的 PCM 参数，位深固定为 16
+type AudioParams struct {
+	SampleRate  int
+	NumChannels int
+}
+
+// FrameParams 为抽帧参数
+type FrameParams struct {
+	FPS     int // 每秒输出的帧数
+	Quality int // JPEG 质量等级，2-31，越小质量越高，与 ffmpeg 的 -qscale:v 一致
+}
+
+// TranscodeParams 为转码参数，空值表示由后端按输出格式选择默认值
+type TranscodeParams struct {
+	Format      string // 输出封装格式，如 "wav"、"mp3"、"mp4"
+	AudioCodec  string // 如 "pcm_s16le"、"aac"
+	VideoCodec  string // 如 "h264"、"mjpeg"
+	SampleRate  int
+	NumChannels int
+}
+
+// MediaInfo 为 Probe 的结果
+type MediaInfo struct {
+	Format   string        // 封装格式，如 "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration time.Duration // 未知时为 0
+	Streams  []StreamInfo
+}
+
+// StreamInfo 为一条音频或视频流的参数，与类型无关的字段为零值
+type StreamInfo struct {
+	Type  string // "audio"、"video" 等
+	Codec string // 如 "h264"、"pcm_s16le"
+
+	SampleRate  int
+	NumChannels int
+
+	Width, Height int
+	FPS           float64 // 平均帧率，未知时为 0
+}
+
+var defaultBackend atomic.Value // backendHolder
+
+// backendHolder 使不同具体类型的后端可以存入同一个 atomic.Value
+type backendHolder struct{ MediaBackend }
+
+// SetDefaultBackend 设置未通过 WithBackend 指定时使用的媒体后端，b 为 nil 表示恢复为 FFmpegBackend，并发调用安全
+func SetDefaultBackend(b MediaBackend) {
+	defaultBackend.Store(backendHolder{b})
+}
+
+// DefaultBackend 返回当前的默认媒体后端
+func DefaultBackend() MediaBackend {
+	if h, _ := defaultBackend.Load().(backendHolder); h.MediaBackend != nil {
+		return h.MediaBackend
+	}
+	return &FFmpegBackend{}
+}
+
+// DecodeAudio 将 r 中的音频解码为 PCM 写入 w，默认输出 24000Hz 单声道，可通过 WithSampleRate、WithNumChannels 修改
+func DecodeAudio(w io.Writer, r io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return err
+	}
+	return o.backend.DecodeAudio(o.ctx, w, r, AudioParams{SampleRate: o.sampleRate, NumChannels: o.numChannels})
+}
+
+// Probe 读取 r 中媒体的封装格式、时长与流信息
+func Probe(r io.Reader, opts ...Option) (*MediaInfo, error) {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return o.backend.Probe(o.ctx, r)
+}
+
+// Transcode 将 r 中的媒体按 params 转换后写入 w
+func Transcode(w io.Writer, r io.Reader, params TranscodeParams, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return err
+	}
+	return o.backend.Transcode(o.ctx, w, r, params)
+}
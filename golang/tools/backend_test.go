@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeBackend 记录收到的输入与参数，每次抽帧把整个输入作为一帧返回
+type fakeBackend struct {
+	input  []byte
+	frames FrameParams
+	audio  AudioParams
+}
+
+func (b *fakeBackend) DecodeAudio(ctx context.Context, w io.Writer, r io.Reader, params AudioParams) error {
+	b.audio = params
+	_, err := io.Copy(w, r)
+	return err
+}
+
+func (b *fakeBackend) ExtractFrames(ctx context.Context, r io.Reader, params FrameParams, onFrame func(frame []byte) error) error {
+	b.frames = params
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b.input = data
+	return onFrame(data)
+}
+
+func (b *fakeBackend) Probe(ctx context.Context, r io.Reader) (*MediaInfo, error) {
+	return &MediaInfo{Format: "fake"}, nil
+}
+
+func (b *fakeBackend) Transcode(ctx context.Context, w io.Writer, r io.Reader, params TranscodeParams) error {
+	return ErrUnsupported
+}
+
+func TestWithBackend(t *testing.T) {
+	sps, pps := string(readTestdata(t, fixtureSPSFile)), string(readTestdata(t, fixturePPSFile))
+	raw := readTestdata(t, fixtureSlices)
+	want, err := InjectSPSPPS(raw, sps, pps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &fakeBackend{}
+	var frames [][]byte
+	err = ExtractFramesStream(bytes.NewReader(raw), func(frame []byte) error {
+		frames = append(frames, frame)
+		return nil
+	}, WithBackend(b), WithSPSPPS(sps, pps), WithFPS(5), WithQuality(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.input, want) || len(frames) != 1 {
+		t.Errorf("backend got %d bytes and returned %d frames, want %d bytes with sps/pps and 1 frame", len(b.input), len(frames), len(want))
+	}
+	if b.frames != (FrameParams{FPS: 5, Quality: 10}) {
+		t.Errorf("frame params = %+v", b.frames)
+	}
+
+	var pcm bytes.Buffer
+	if err := DecodeAudio(&pcm, bytes.NewReader([]byte{1, 2}), WithBackend(b), WithSampleRate(16000)); err != nil {
+		t.Fatal(err)
+	}
+	if b.audio != (AudioParams{SampleRate: 16000, NumChannels: DefaultNumChannels}) || pcm.Len() != 2 {
+		t.Errorf("audio params = %+v, output %d bytes", b.audio, pcm.Len())
+	}
+	if info, err := Probe(bytes.NewReader(nil), WithBackend(b)); err != nil || info.Format != "fake" {
+		t.Errorf("Probe() = %+v, %v", info, err)
+	}
+	if err := Transcode(io.Discard, bytes.NewReader(nil), TranscodeParams{Format: "wav"}, WithBackend(b)); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Transcode() err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestSetDefaultBackend(t *testing.T) {
+	b := &fakeBackend{}
+	SetDefaultBackend(b)
+	t.Cleanup(func() { SetDefaultBackend(nil) })
+	if DefaultBackend() != b {
+		t.Fatal("DefaultBackend() did not return the backend set")
+	}
+
+	// ExtractFramesToBase64 仍通过临时目录中的输入文件交给后端，结束后清理
+	dir := t.TempDir()
+	data := readTestdata(t, fixtureH264)
+	frames, err := ExtractFramesToBase64(data, WithTempDir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 1 || !bytes.Equal(frames[0], data) {
+		t.Errorf("got %d frames, want the input passed through", len(frames))
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("temp dir contains %v after ExtractFramesToBase64", names)
+	}
+
+	SetDefaultBackend(nil)
+	if _, ok := DefaultBackend().(*FFmpegBackend); !ok {
+		t.Errorf("DefaultBackend() = %T after reset, want *FFmpegBackend", DefaultBackend())
+	}
+}
+
+func TestFFmpegBackendMissingBinary(t *testing.T) {
+	b := &FFmpegBackend{FFmpegPath: filepath.Join(t.TempDir(), "ffmpeg"), FFprobePath: filepath.Join(t.TempDir(), "ffprobe")}
+	err := b.ExtractFrames(context.Background(), bytes.NewReader(nil), FrameParams{FPS: 1, Quality: 2}, func([]byte) error { return nil })
+	if !errors.Is(err, ErrFFmpegFailed) {
+		t.Errorf("ExtractFrames() err = %v, want ErrFFmpegFailed", err)
+	}
+	if _, err := b.Probe(context.Background(), bytes.NewReader(nil)); !errors.Is(err, ErrFFmpegFailed) {
+		t.Errorf("Probe() err = %v, want ErrFFmpegFailed", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.DecodeAudio(ctx, io.Discard, bytes.NewReader(nil), AudioParams{}); err != context.Canceled {
+		t.Errorf("DecodeAudio() err = %v, want context.Canceled", err)
+	}
+}
+
+func TestParseProbe(t *testing.T) {
+	info, err := parseProbe([]byte(`{
+		"streams": [
+			{"codec_type": "video", "codec_name": "h264", "width": 32, "height": 32, "avg_frame_rate": "25/1"},
+			{"codec_type": "audio", "codec_name": "aac", "sample_rate": "24000", "channels": 1, "avg_frame_rate": "0/0"}
+		],
+		"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "0.800000"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != "mov,mp4,m4a,3gp,3g2,mj2" || info.Duration != 800*time.Millisecond || len(info.Streams) != 2 {
+		t.Fatalf("info = %+v", info)
+	}
+	if v := info.Streams[0]; v != (StreamInfo{Type: "video", Codec: "h264", Width: 32, Height: 32, FPS: 25}) {
+		t.Errorf("video stream = %+v", v)
+	}
+	if a := info.Streams[1]; a != (StreamInfo{Type: "audio", Codec: "aac", SampleRate: 24000, NumChannels: 1}) {
+		t.Errorf("audio stream = %+v", a)
+	}
+	if _, err := parseProbe([]byte("not json")); !errors.Is(err, ErrFFmpegFailed) {
+		t.Errorf("invalid output: err = %v, want ErrFFmpegFailed", err)
+	}
+}
+
+func TestFFmpegBackendProbe(t *testing.T) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skip("ffprobe not found in PATH")
+	}
+	f, err := os.Open(filepath.Join(testdataDir, fixtureMP4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := Probe(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Streams) != 1 || info.Streams[0].Codec != "h264" ||
+		info.Streams[0].Width != fixtureWidth || info.Streams[0].Height != fixtureHeight {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestFFmpegBackendDecodeAudio(t *testing.T) {
+	requireFFmpeg(t)
+	// 16000Hz 的 WAV 重采样为默认的 24000Hz 单声道
+	var pcm bytes.Buffer
+	if err := DecodeAudio(&pcm, bytes.NewReader(readTestdata(t, "sine_16000_16_mono.wav"))); err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultSampleRate * fixtureSineMs / 1000 * 2
+	if n := pcm.Len(); n < want*9/10 || n > want*11/10 {
+		t.Errorf("decoded %d bytes, want about %d", n, want)
+	}
+}
+
+// blockingReader 返回一次数据后阻塞，直到 release 被关闭，记录进行中的 Read 数
+type blockingReader struct {
+	release chan struct{}
+	reads   atomic.Int32
+	active  atomic.Int32
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	r.active.Add(1)
+	defer r.active.Add(-1)
+	if r.reads.Add(1) > 1 {
+		<-r.release
+	}
+	return copy(p, make([]byte, 4096)), nil
+}
+
+func TestFFmpegBackendStopsReadingInput(t *testing.T) {
+	// false 立即以非零状态退出而不读取标准输入
+	path, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("false not found in PATH")
+	}
+	r := &blockingReader{release: make(chan struct{})}
+	time.AfterFunc(100*time.Millisecond, func() { close(r.release) })
+	b := &FFmpegBackend{FFmpegPath: path}
+	err = b.ExtractFrames(context.Background(), r, FrameParams{FPS: 1, Quality: 2}, func([]byte) error { return nil })
+	if !errors.Is(err, ErrFFmpegFailed) {
+		t.Errorf("ExtractFrames() err = %v, want ErrFFmpegFailed", err)
+	}
+	if n := r.active.Load(); n != 0 {
+		t.Fatalf("%d reads of the input still in progress after ExtractFrames returned", n)
+	}
+	reads := r.reads.Load()
+	time.Sleep(50 * time.Millisecond)
+	if n := r.reads.Load(); n != reads {
+		t.Errorf("input read %d more times after ExtractFrames returned", n-reads)
+	}
+}
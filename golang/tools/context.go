@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"io"
+)
+
+// 以下 XxxContext 函数与对应的同名函数行为一致，额外在各处理步骤之间检查 ctx，
+// ctx 被取消后立即停止并返回 ctx.Err()，正在运行的 ffmpeg 进程也会被终止。
+// 适用于 HTTP handler 等需要在客户端断开后及时释放资源的场景。
+
+// ConcatWavBytesContext 是支持取消的 ConcatWavBytes
+func ConcatWavBytesContext(ctx context.Context, wavBytes [][]byte, opts ...Option) ([]byte, error) {
+	return ConcatWavBytes(wavBytes, withContext(ctx, opts)...)
+}
+
+// Pcm2WavContext 是支持取消的 Pcm2Wav
+func Pcm2WavContext(ctx context.Context, pcmBytes []byte, opts ...Option) ([]byte, error) {
+	return Pcm2Wav(pcmBytes, withContext(ctx, opts)...)
+}
+
+// ExtractFramesToBase64Context 是支持取消的 ExtractFramesToBase64
+func ExtractFramesToBase64Context(ctx context.Context, data []byte, opts ...Option) ([][]byte, error) {
+	return ExtractFramesToBase64(data, withContext(ctx, opts)...)
+}
+
+// ConcatWavStreamContext 是支持取消的 ConcatWavStream
+func ConcatWavStreamContext(ctx context.Context, w io.Writer, readers []io.Reader, opts ...Option) error {
+	return ConcatWavStream(w, readers, withContext(ctx, opts)...)
+}
+
+// Pcm2WavStreamContext 是支持取消的 Pcm2WavStream
+func Pcm2WavStreamContext(ctx context.Context, w io.Writer, r io.Reader, dataSize int64, opts ...Option) error {
+	return Pcm2WavStream(w, r, dataSize, withContext(ctx, opts)...)
+}
+
+// ExtractFramesStreamContext 是支持取消的 ExtractFramesStream
+func ExtractFramesStreamContext(ctx context.Context, r io.Reader, onFrame func(frame []byte) error, opts ...Option) error {
+	return ExtractFramesStream(r, onFrame, withContext(ctx, opts)...)
+}
+
+// DecodeAudioContext 是支持取消的 DecodeAudio
+func DecodeAudioContext(ctx context.Context, w io.Writer, r io.Reader, opts ...Option) error {
+	return DecodeAudio(w, r, withContext(ctx, opts)...)
+}
+
+// ProbeContext 是支持取消的 Probe
+func ProbeContext(ctx context.Context, r io.Reader, opts ...Option) (*MediaInfo, error) {
+	return Probe(r, withContext(ctx, opts)...)
+}
+
+// TranscodeContext 是支持取消的 Transcode
+func TranscodeContext(ctx context.Context, w io.Writer, r io.Reader, params TranscodeParams, opts ...Option) error {
+	return Transcode(w, r, params, withContext(ctx, opts)...)
+}
+
+// withContext 将 ctx 追加到 opts 末尾，使其优先于 opts 中的 WithContext
+func withContext(ctx context.Context, opts []Option) []Option {
+	return append(opts[:len(opts):len(opts)], WithContext(ctx))
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContextVariantsHonorCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wavData, err := Pcm2Wav(make([]byte, 480))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConcatWavBytesContext(ctx, [][]byte{wavData, wavData}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ConcatWavBytesContext err = %v, want context.Canceled", err)
+	}
+	if _, err := Pcm2WavContext(ctx, make([]byte, 480)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Pcm2WavContext err = %v, want context.Canceled", err)
+	}
+	if _, err := ExtractFramesToBase64Context(ctx, []byte{0, 0, 0, 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExtractFramesToBase64Context err = %v, want context.Canceled", err)
+	}
+	// 未取消的 context 不影响正常处理
+	if _, err := ConcatWavBytesContext(context.Background(), [][]byte{wavData, wavData}); err != nil {
+		t.Errorf("ConcatWavBytesContext err = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"errors"
+)
+
+// tools 包返回的错误均包装自以下哨兵错误，调用方可以通过 errors.Is 判断失败原因，
+// 错误信息统一使用英文，便于日志检索
+var (
+	// ErrEmptyInput 表示没有可处理的输入数据
+	ErrEmptyInput = errors.New("tools: empty input")
+	// ErrInvalidWav 表示输入不是合法的 WAV 数据
+	ErrInvalidWav = errors.New("tools: invalid wav data")
+	// ErrFormatMismatch 表示多段音频的采样率或声道数不一致
+	ErrFormatMismatch = errors.New("tools: audio format mismatch")
+	// ErrInvalidSPSPPS 表示 SPS/PPS 无法解码
+	ErrInvalidSPSPPS = errors.New("tools: invalid sps/pps")
+	// ErrFFmpegFailed 表示调用 ffmpeg 或 ffprobe 失败
+	ErrFFmpegFailed = errors.New("tools: ffmpeg failed")
+	// ErrUnsupported 表示媒体后端不支持所请求的操作
+	ErrUnsupported = errors.New("tools: unsupported by media backend")
+)
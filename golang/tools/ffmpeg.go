@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FFmpegBackend 为默认的媒体后端，调用 ffmpeg/ffprobe 可执行文件完成处理，零值即可使用
+// 输入为位于开头的 *os.File 时按路径交给 ffmpeg，其余输入通过标准输入传入；
+// MP4 等索引位于文件末尾的格式通过管道传入时可能无法识别，此时应传入文件
+type FFmpegBackend struct {
+	FFmpegPath  string // 默认为 PATH 中的 ffmpeg
+	FFprobePath string // 默认为 PATH 中的 ffprobe
+}
+
+func (b *FFmpegBackend) ffmpeg() string {
+	if b.FFmpegPath != "" {
+		return b.FFmpegPath
+	}
+	return "ffmpeg"
+}
+
+func (b *FFmpegBackend) ffprobe() string {
+	if b.FFprobePath != "" {
+		return b.FFprobePath
+	}
+	return "ffprobe"
+}
+
+// DecodeAudio 实现 MediaBackend
+func (b *FFmpegBackend) DecodeAudio(ctx context.Context, w io.Writer, r io.Reader, params AudioParams) error {
+	in, stdin := ffmpegInput(r)
+	args := append(in, "-vn", "-f", "s16le", "-acodec", "pcm_s16le",
+		"-ar", strconv.Itoa(params.SampleRate), "-ac", strconv.Itoa(params.NumChannels), "pipe:1")
+	return runFFmpeg(ctx, b.ffmpeg(), args, stdin, func(stdout io.Reader) error {
+		_, err := io.Copy(w, stdout)
+		return err
+	})
+}
+
+// ExtractFrames 实现 MediaBackend
+func (b *FFmpegBackend) ExtractFrames(ctx context.Context, r io.Reader, params FrameParams, onFrame func(frame []byte) error) error {
+	in, stdin := ffmpegInput(r)
+	args := append([]string{"-f", "h264"}, in...)
+	args = append(args,
+		"-vf", fmt.Sprintf("fps=%d", params.FPS), // 每秒抽帧数
+		"-qscale:v", strconv.Itoa(params.Quality), // JPEG 质量
+		"-f", "image2pipe", "-c:v", "mjpeg", "pipe:1")
+	return runFFmpeg(ctx, b.ffmpeg(), args, stdin, func(stdout io.Reader) error {
+		br := bufio.NewReader(stdout)
+		for {
+			frame, err := readJPEG(br)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := onFrame(frame); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// Probe 实现 MediaBackend
+func (b *FFmpegBackend) Probe(ctx context.Context, r io.Reader) (*MediaInfo, error) {
+	in, stdin := ffmpegInput(r)
+	args := append([]string{"-v", "error", "-of", "json", "-show_format", "-show_streams"}, in[len(in)-1])
+	var out bytes.Buffer
+	err := runFFmpeg(ctx, b.ffprobe(), args, stdin, func(stdout io.Reader) error {
+		_, err := io.Copy(&out, stdout)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseProbe(out.Bytes())
+}
+
+// Transcode 实现 MediaBackend
+func (b *FFmpegBackend) Transcode(ctx context.Context, w io.Writer, r io.Reader, params TranscodeParams) error {
+	if params.Format == "" {
+		return fmt.Errorf("%w: transcode output format is empty", ErrUnsupported)
+	}
+	args, stdin := ffmpegInput(r)
+	if params.AudioCodec != "" {
+		args = append(args, "-c:a", params.AudioCodec)
+	}
+	if params.VideoCodec != "" {
+		args = append(args, "-c:v", params.VideoCodec)
+	}
+	if params.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(params.SampleRate))
+	}
+	if params.NumChannels > 0 {
+		args = append(args, "-ac", strconv.Itoa(params.NumChannels))
+	}
+	if params.Format == "mp4" || params.Format == "mov" {
+		// 输出为管道时无法回写索引，改用分片格式
+		args = append(args, "-movflags", "frag_keyframe+empty_moov")
+	}
+	args = append(args, "-f", params.Format, "pipe:1")
+	return runFFmpeg(ctx, b.ffmpeg(), args, stdin, func(stdout io.Reader) error {
+		_, err := io.Copy(w, stdout)
+		return err
+	})
+}
+
+// ffmpegInput 返回 r 对应的输入参数，需要通过标准输入传入时同时返回 r
+// ffprobe 只使用其中最后一项，即输入地址
+func ffmpegInput(r io.Reader) (args []string, stdin io.Reader) {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			if off, err := f.Seek(0, io.SeekCurrent); err == nil && off == 0 {
+				// -nostdin 避免 ffmpeg 读取终端输入；file: 前缀避免路径中的冒号被当作协议名
+				return []string{"-nostdin", "-i", "file:" + f.Name()}, nil
+			}
+		}
+	}
+	return []string{"-i", "pipe:0"}, r
+}
+
+// runFFmpeg 运行 ffmpeg 或 ffprobe，stdin 不为 nil 时写入其标准输入，标准输出交给 output 处理
+// output 返回错误时终止进程并返回该错误；ctx 取消时返回 ctx.Err() 而不是进程退出错误
+// 返回前总会等待写入输入的 goroutine 结束，返回后不再读取 stdin；stdin 的 Read 阻塞时会等到其返回
+func runFFmpeg(ctx context.Context, name string, args []string, stdin io.Reader, output func(stdout io.Reader) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cmdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, name, args...)
+	cmd.Stderr = os.Stderr
+	var pipe io.WriteCloser
+	if stdin != nil {
+		var err error
+		if pipe, err = cmd.StdinPipe(); err != nil {
+			return err
+		}
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Running command: %v", cmd.Args)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%w: %w", ErrFFmpegFailed, err)
+	}
+
+	// 输入在单独的 goroutine 中写入，避免与读取输出互相阻塞
+	// 写 stdin 失败说明进程已退出，原因由 Wait 报告，这里只记录读取输入的错误
+	// 进程退出后 Wait 关闭管道，goroutine 在当前的 Read 返回后随写入失败而结束
+	src := &inputReader{r: stdin}
+	inputDone := make(chan struct{})
+	go func() {
+		defer close(inputDone)
+		if pipe != nil {
+			defer pipe.Close()
+			_, _ = io.Copy(pipe, src)
+		}
+	}()
+
+	outputErr := output(stdout)
+	if outputErr != nil {
+		cancel()
+	}
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+	<-inputDone
+	var readErr error
+	if waitErr == nil {
+		// 进程正常结束意味着输入已读取完毕，此时的读取错误才是失败原因
+		readErr = src.err
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case outputErr != nil:
+		return outputErr
+	case readErr != nil:
+		return fmt.Errorf("read input failed: %w", readErr)
+	case waitErr != nil:
+		return fmt.Errorf("%w: %w", ErrFFmpegFailed, waitErr)
+	}
+	return nil
+}
+
+// inputReader 记录读取输入时发生的错误，以便与写入 ffmpeg stdin 的错误区分
+type inputReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *inputReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// probeOutput 为 ffprobe -of json 输出中用到的字段，数值字段多以字符串表示
+type probeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		SampleRate   string `json:"sample_rate"`
+		Channels     int    `json:"channels"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+	} `json:"streams"`
+}
+
+// parseProbe 将 ffprobe 的输出转换为 MediaInfo，无法解析的可选字段保留零值
+func parseProbe(data []byte) (*MediaInfo, error) {
+	var out probeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("%w: parse ffprobe output: %w", ErrFFmpegFailed, err)
+	}
+	info := &MediaInfo{Format: out.Format.FormatName}
+	if seconds, err := strconv.ParseFloat(out.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	for _, s := range out.Streams {
+		stream := StreamInfo{
+			Type:        s.CodecType,
+			Codec:       s.CodecName,
+			NumChannels: s.Channels,
+			Width:       s.Width,
+			Height:      s.Height,
+		}
+		stream.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		// 帧率形如 "25/1"，未知时为 "0/0"
+		if num, den, ok := strings.Cut(s.AvgFrameRate, "/"); ok {
+			n, err1 := strconv.ParseFloat(num, 64)
+			d, err2 := strconv.ParseFloat(den, 64)
+			if err1 == nil && err2 == nil && d != 0 {
+				stream.FPS = n / d
+			}
+		}
+		info.Streams = append(info.Streams, stream)
+	}
+	return info, nil
+}
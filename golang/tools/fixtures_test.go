@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试夹具全部由本文件中的代码确定性地生成，并提交在 testdata 目录下，
+// 修改生成逻辑或被测函数的输出后，执行 go test ./tools -update 重新生成。
+
+var update = flag.Bool("update", false, "regenerate testdata fixtures and golden files")
+
+// sineFixtures 为不同采样率、位深度、声道数的正弦波 WAV
+var sineFixtures = []struct {
+	name                             string
+	sampleRate, bitDepth, numChannel int
+}{
+	{"sine_8000_8_mono.wav", 8000, 8, 1},
+	{"sine_16000_16_mono.wav", 16000, 16, 1},
+	{"sine_24000_16_mono.wav", 24000, 16, 1},
+	{"sine_44100_16_stereo.wav", 44100, 16, 2},
+	{"sine_48000_24_stereo.wav", 48000, 24, 2},
+}
+
+const (
+	fixtureSineHz  = 440
+	fixtureSineMs  = 100
+	fixtureWidth   = 32 // H.264 夹具的宽高，需为 16 的倍数
+	fixtureHeight  = 32
+	fixtureFrames  = 20
+	fixtureFPS     = 25
+	fixtureH264    = "tiny.h264"    // 带 SPS/PPS 的完整 Annex B 码流
+	fixtureSlices  = "tiny.slices"  // 不带 SPS/PPS 的码流，与客户端上传的视频数据形式一致
+	fixtureMP4     = "tiny.mp4"     // 与 tiny.h264 内容相同的 MP4 封装
+	fixtureSPSFile = "tiny.sps.b64" // base64 编码的 SPS
+	fixturePPSFile = "tiny.pps.b64" // base64 编码的 PPS
+	testdataDir    = "testdata"
+)
+
+// sineWav 生成 duration 毫秒、频率 freq 的正弦波 WAV，各声道相位依次错开
+func sineWav(sampleRate, bitDepth, numChannels int, freq float64, durationMs int) []byte {
+	frames := sampleRate * durationMs / 1000
+	bytesPerSample := bitDepth / 8
+	pcm := make([]byte, 0, frames*numChannels*bytesPerSample)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < numChannels; c++ {
+			v := 0.8 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)+float64(c)*math.Pi/2)
+			switch bitDepth {
+			case 8: // 8 位 PCM 为无符号数
+				pcm = append(pcm, uint8(math.Round(v*127)+128))
+			case 16:
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(math.Round(v*32767))))
+			case 24:
+				s := uint32(int32(math.Round(v * 8388607)))
+				pcm = append(pcm, byte(s), byte(s>>8), byte(s>>16))
+			}
+		}
+	}
+	wavData, err := Pcm2Wav(pcm, WithSampleRate(sampleRate), WithBitDepth(bitDepth), WithNumChannels(numChannels))
+	if err != nil {
+		panic(err)
+	}
+	return wavData
+}
+
+// bitWriter 按 H.264 语法写入比特流
+type bitWriter struct {
+	buf  []byte
+	nbit int
+}
+
+func (w *bitWriter) u(n int, v uint64) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbit%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.buf[len(w.buf)-1] |= 0x80 >> uint(w.nbit%8)
+		}
+		w.nbit++
+	}
+}
+
+// ue 写入无符号指数哥伦布码
+func (w *bitWriter) ue(v uint64) {
+	n := 0
+	for (v+1)>>uint(n+1) != 0 {
+		n++
+	}
+	w.u(n, 0)
+	w.u(n+1, v+1)
+}
+
+// se 写入有符号指数哥伦布码
+func (w *bitWriter) se(v int64) {
+	if v > 0 {
+		w.ue(uint64(2*v - 1))
+	} else {
+		w.ue(uint64(-2 * v))
+	}
+}
+
+func (w *bitWriter) align() {
+	for w.nbit%8 != 0 {
+		w.u(1, 0)
+	}
+}
+
+// trailing 写入 rbsp_trailing_bits
+func (w *bitWriter) trailing() {
+	w.u(1, 1)
+	w.align()
+}
+
+// nalUnit 为 RBSP 添加 NAL 头并插入防竞争字节
+func nalUnit(header byte, rbsp []byte) []byte {
+	nal := []byte{header}
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= 3 {
+			nal = append(nal, 3)
+			zeros = 0
+		}
+		nal = append(nal, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return nal
+}
+
+// h264Fixture 生成 Baseline Profile 的 H.264 码流：SPS、PPS 以及若干帧 I_PCM 宏块组成的 I 帧，
+// 第一帧为 IDR，其余为非 IDR 的 I 帧。I_PCM 直接存储像素，无需实现编码器即可得到合法码流。
+func h264Fixture() (sps, pps []byte, frames [][]byte) {
+	mbW, mbH := fixtureWidth/16, fixtureHeight/16
+
+	var w bitWriter
+	w.u(8, 66)   // profile_idc: Baseline
+	w.u(8, 0xC0) // constraint_set0_flag, constraint_set1_flag
+	w.u(8, 30)   // level_idc: 3.0
+	w.ue(0)      // seq_parameter_set_id
+	w.ue(0)      // log2_max_frame_num_minus4
+	w.ue(2)      // pic_order_cnt_type
+	w.ue(1)      // max_num_ref_frames
+	w.u(1, 0)    // gaps_in_frame_num_value_allowed_flag
+	w.ue(uint64(mbW - 1))
+	w.ue(uint64(mbH - 1))
+	w.u(1, 1) // frame_mbs_only_flag
+	w.u(1, 1) // direct_8x8_inference_flag
+	w.u(1, 0) // frame_cropping_flag
+	w.u(1, 0) // vui_parameters_present_flag
+	w.trailing()
+	sps = nalUnit(0x67, w.buf)
+
+	w = bitWriter{}
+	w.ue(0)   // pic_parameter_set_id
+	w.ue(0)   // seq_parameter_set_id
+	w.u(1, 0) // entropy_coding_mode_flag: CAVLC
+	w.u(1, 0) // bottom_field_pic_order_in_frame_present_flag
+	w.ue(0)   // num_slice_groups_minus1
+	w.ue(0)   // num_ref_idx_l0_default_active_minus1
+	w.ue(0)   // num_ref_idx_l1_default_active_minus1
+	w.u(1, 0) // weighted_pred_flag
+	w.u(2, 0) // weighted_bipred_idc
+	w.se(0)   // pic_init_qp_minus26
+	w.se(0)   // pic_init_qs_minus26
+	w.se(0)   // chroma_qp_index_offset
+	w.u(1, 0) // deblocking_filter_control_present_flag
+	w.u(1, 0) // constrained_intra_pred_flag
+	w.u(1, 0) // redundant_pic_cnt_present_flag
+	w.trailing()
+	pps = nalUnit(0x68, w.buf)
+
+	for f := 0; f < fixtureFrames; f++ {
+		idr := f == 0
+		w = bitWriter{}
+		w.ue(0)              // first_mb_in_slice
+		w.ue(7)              // slice_type: I（整帧均为 I slice）
+		w.ue(0)              // pic_parameter_set_id
+		w.u(4, uint64(f%16)) // frame_num
+		if idr {
+			w.ue(0)   // idr_pic_id
+			w.u(1, 0) // no_output_of_prior_pics_flag
+			w.u(1, 0) // long_term_reference_flag
+		} else {
+			w.u(1, 0) // adaptive_ref_pic_marking_mode_flag
+		}
+		w.se(0) // slice_qp_delta
+		for mb := 0; mb < mbW*mbH; mb++ {
+			w.ue(25) // mb_type: I_PCM
+			w.align()
+			mbX, mbY := mb%mbW, mb/mbW
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					// 随帧号移动的斜向渐变，取值限制在 [16, 235]
+					v := (mbX*16 + x + mbY*16 + y + f*4) % 220
+					w.u(8, uint64(16+v))
+				}
+			}
+			for c := 0; c < 2; c++ {
+				for i := 0; i < 64; i++ {
+					w.u(8, uint64(128+(c*2-1)*(f*3%64)))
+				}
+			}
+		}
+		w.trailing()
+		header := byte(0x61)
+		if idr {
+			header = 0x65
+		}
+		frames = append(frames, nalUnit(header, w.buf))
+	}
+	return sps, pps, frames
+}
+
+// annexB 将 NAL 单元按 Annex B 格式拼接
+func annexB(nals ...[]byte) []byte {
+	var out []byte
+	for _, nal := range nals {
+		out = append(out, 0, 0, 0, 1)
+		out = append(out, nal...)
+	}
+	return out
+}
+
+// mp4Box 写出一个 ISO BMFF box
+func mp4Box(typ string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	box := binary.BigEndian.AppendUint32(make([]byte, 0, size), uint32(size))
+	box = append(box, typ...)
+	for _, p := range payload {
+		box = append(box, p...)
+	}
+	return box
+}
+
+func be16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func be32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+
+// mp4Matrix 为单位变换矩阵
+var mp4Matrix = bytes.Join([][]byte{
+	be32(0x00010000), be32(0), be32(0),
+	be32(0), be32(0x00010000), be32(0),
+	be32(0), be32(0), be32(0x40000000),
+}, nil)
+
+// mp4Fixture 将 h264Fixture 的码流封装为只有一条视频轨的最小 MP4
+func mp4Fixture() []byte {
+	sps, pps, frames := h264Fixture()
+
+	ftyp := mp4Box("ftyp", []byte("isom"), be32(0x200), []byte("isomiso2avc1mp41"))
+
+	var samples []byte
+	var sizes []byte
+	for _, frame := range frames {
+		sample := append(be32(uint32(len(frame))), frame...) // AVCC：4 字节长度前缀
+		samples = append(samples, sample...)
+		sizes = append(sizes, be32(uint32(len(sample)))...)
+	}
+	mdat := mp4Box("mdat", samples)
+	n := uint32(len(frames))
+	durationMs := n * 1000 / fixtureFPS
+
+	mvhd := mp4Box("mvhd", be32(0), be32(0), be32(0), be32(1000), be32(durationMs),
+		be32(0x00010000), be16(0x0100), make([]byte, 10), mp4Matrix, make([]byte, 24), be32(2))
+	tkhd := mp4Box("tkhd", be32(3), be32(0), be32(0), be32(1), be32(0), be32(durationMs),
+		make([]byte, 8), be16(0), be16(0), be16(0), be16(0), mp4Matrix,
+		be32(fixtureWidth<<16), be32(fixtureHeight<<16))
+	mdhd := mp4Box("mdhd", be32(0), be32(0), be32(0), be32(fixtureFPS), be32(n), be16(0x55C4), be16(0))
+	hdlr := mp4Box("hdlr", be32(0), be32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"))
+	vmhd := mp4Box("vmhd", be32(1), make([]byte, 8))
+	dinf := mp4Box("dinf", mp4Box("dref", be32(0), be32(1), mp4Box("url ", be32(1))))
+
+	avcC := mp4Box("avcC", []byte{1, sps[1], sps[2], sps[3], 0xFF, 0xE1}, be16(uint16(len(sps))), sps,
+		[]byte{1}, be16(uint16(len(pps))), pps)
+	avc1 := mp4Box("avc1", make([]byte, 6), be16(1), make([]byte, 16),
+		be16(fixtureWidth), be16(fixtureHeight), be32(0x00480000), be32(0x00480000), be32(0), be16(1),
+		make([]byte, 32), be16(0x0018), be16(0xFFFF), avcC)
+	stsd := mp4Box("stsd", be32(0), be32(1), avc1)
+	stts := mp4Box("stts", be32(0), be32(1), be32(n), be32(1))
+	stsc := mp4Box("stsc", be32(0), be32(1), be32(1), be32(n), be32(1))
+	stsz := mp4Box("stsz", be32(0), be32(0), be32(n), sizes)
+	stco := mp4Box("stco", be32(0), be32(1), be32(uint32(len(ftyp)+8))) // 数据紧跟在 ftyp 与 mdat 头之后
+
+	stbl := mp4Box("stbl", stsd, stts, stsc, stsz, stco)
+	minf := mp4Box("minf", vmhd, dinf, stbl)
+	mdia := mp4Box("mdia", mdhd, hdlr, minf)
+	moov := mp4Box("moov", mvhd, mp4Box("trak", tkhd, mdia))
+
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+// generateFixtures 返回 testdata 目录下全部夹具文件名与内容
+func generateFixtures() map[string][]byte {
+	files := map[string][]byte{}
+	for _, f := range sineFixtures {
+		files[f.name] = sineWav(f.sampleRate, f.bitDepth, f.numChannel, fixtureSineHz, fixtureSineMs)
+	}
+	sps, pps, frames := h264Fixture()
+	files[fixtureH264] = annexB(append([][]byte{sps, pps}, frames...)...)
+	files[fixtureSlices] = annexB(frames...)
+	files[fixtureMP4] = mp4Fixture()
+	files[fixtureSPSFile] = []byte(b64(sps))
+	files[fixturePPSFile] = []byte(b64(pps))
+	return files
+}
+
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testdataDir, name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v (run go test ./tools -update to regenerate)", name, err)
+	}
+	return data
+}
+
+// checkGolden 将 got 与 testdata 下的 golden 文件比较，-update 时改为写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(testdataDir, name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if want := readTestdata(t, name); !bytes.Equal(got, want) {
+		t.Errorf("%s: output (%d bytes) differs from golden file (%d bytes)", name, len(got), len(want))
+	}
+}
+
+// 提交的夹具必须与生成器的输出一致，保证夹具可以被重新生成和审查
+func TestFixturesUpToDate(t *testing.T) {
+	if *update {
+		if err := os.MkdirAll(testdataDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range generateFixtures() {
+		checkGolden(t, name, data)
+	}
+}
+
+func b64(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+)
+
+// 以下模糊测试覆盖解析用户上传数据的函数，要求任意输入都不会 panic。
+// 运行方式：go test ./tools -run '^$' -fuzz FuzzReadWavHeader -fuzztime 1m
+
+func addWavSeeds(f *testing.F) {
+	for _, fx := range sineFixtures {
+		f.Add(readTestdata(f, fx.name))
+	}
+	f.Add([]byte("RIFF\x00\x00\x00\x00WAVE"))
+	f.Add([]byte{})
+}
+
+func FuzzReadWavHeader(f *testing.F) {
+	addWavSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, err := ReadWavHeader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if header.NumChannels <= 0 || header.SampleRate <= 0 || header.BitDepth <= 0 || header.DataSize < -1 {
+			t.Fatalf("invalid header accepted: %+v", header)
+		}
+	})
+}
+
+func FuzzConcatWavBytes(f *testing.F) {
+	addWavSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		valid, _ := Pcm2Wav(make([]byte, 64))
+		_, _ = ConcatWavBytes([][]byte{valid, data}, WithTempDir(t.TempDir()))
+		_ = ConcatWavStream(io.Discard, []io.Reader{bytes.NewReader(valid), bytes.NewReader(data)})
+	})
+}
+
+func FuzzInjectSPSPPS(f *testing.F) {
+	f.Add(readTestdata(f, fixtureSlices), string(readTestdata(f, fixtureSPSFile)), string(readTestdata(f, fixturePPSFile)))
+	f.Add([]byte{}, "Z0LADJoFAAABMA==", "aM48gA==")
+	f.Fuzz(func(t *testing.T, data []byte, sps, pps string) {
+		out, err := InjectSPSPPS(data, sps, pps)
+		if err != nil {
+			return
+		}
+		rawSPS, _ := base64.StdEncoding.DecodeString(sps)
+		rawPPS, _ := base64.StdEncoding.DecodeString(pps)
+		if len(out) != 8+len(rawSPS)+len(rawPPS)+len(data) {
+			t.Fatalf("output length %d is wrong", len(out))
+		}
+	})
+}
+
+// ExtractFramesToBase64 与 ExtractFramesStream 使用 fakeBackend，覆盖抽帧前的 SPS/PPS 注入与输入处理
+func FuzzExtractFrames(f *testing.F) {
+	f.Add(readTestdata(f, fixtureSlices), string(readTestdata(f, fixtureSPSFile)), string(readTestdata(f, fixturePPSFile)))
+	f.Add(readTestdata(f, fixtureH264), "", "")
+	f.Add([]byte{0, 0, 0, 1, 0x65}, "Z0LADJoFAAABMA==", "")
+	f.Fuzz(func(t *testing.T, data []byte, sps, pps string) {
+		want := data
+		if sps != "" || pps != "" {
+			var err error
+			if want, err = InjectSPSPPS(data, sps, pps); err != nil {
+				want = nil
+			}
+		}
+
+		b := &fakeBackend{}
+		images, err := ExtractFramesToBase64(data, WithBackend(b), WithSPSPPS(sps, pps), WithTempDir(t.TempDir()))
+		if (err == nil) != (want != nil) {
+			t.Fatalf("ExtractFramesToBase64() err = %v, InjectSPSPPS ok = %v", err, want != nil)
+		}
+		if err == nil && (len(images) != 1 || !bytes.Equal(images[0], want)) {
+			t.Fatalf("ExtractFramesToBase64 passed %d bytes to the backend, want %d", len(b.input), len(want))
+		}
+
+		b = &fakeBackend{}
+		err = ExtractFramesStream(bytes.NewReader(data), func([]byte) error { return nil }, WithBackend(b), WithSPSPPS(sps, pps))
+		if (err == nil) != (want != nil) {
+			t.Fatalf("ExtractFramesStream() err = %v, InjectSPSPPS ok = %v", err, want != nil)
+		}
+		if err == nil && !bytes.Equal(b.input, want) {
+			t.Fatalf("ExtractFramesStream passed %d bytes to the backend, want %d", len(b.input), len(want))
+		}
+	})
+}
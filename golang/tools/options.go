@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+)
+
+// Option 用于配置 tools 包中的媒体处理函数，未设置的项使用默认值
+// 新增配置项只需增加新的 WithXxx 函数，不会破坏已有调用方的函数签名
+type Option func(*options)
+
+type options struct {
+	ctx           context.Context
+	tempDir       string
+	keepOnFailure bool
+	backend       MediaBackend
+
+	// 音频参数，Pcm2Wav 使用
+	sampleRate  int
+	numChannels int
+	bitDepth    int
+
+	// 抽帧参数，ExtractFramesToBase64、ExtractFramesStream 使用
+	fps      int
+	quality  int
+	sps, pps string
+}
+
+const (
+	DefaultSampleRate  = 24000 // 服务端输出音频的采样率
+	DefaultNumChannels = 1
+	DefaultBitDepth    = 16
+	DefaultFPS         = 2 // 每秒抽取的帧数
+	DefaultQuality     = 2 // ffmpeg -qscale:v 取值，2 为高质量 JPEG
+)
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ctx:         context.Background(),
+		tempDir:     DefaultTempDir(),
+		backend:     DefaultBackend(),
+		sampleRate:  DefaultSampleRate,
+		numChannels: DefaultNumChannels,
+		bitDepth:    DefaultBitDepth,
+		fps:         DefaultFPS,
+		quality:     DefaultQuality,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithContext 设置处理过程使用的 context，取消后在下一个处理步骤前返回 ctx.Err()，
+// 正在运行的外部进程（ffmpeg）也会被终止
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// WithTempDir 设置临时文件所在目录，默认为 DefaultTempDir()
+func WithTempDir(dir string) Option {
+	return func(o *options) {
+		o.tempDir = dir
+	}
+}
+
+// WithKeepTempOnFailure 设置处理失败时保留临时文件，便于排查问题，保留的路径会写入日志
+// 保留下来的文件不会自动删除，可通过 SweepTempDir 清理
+func WithKeepTempOnFailure(keep bool) Option {
+	return func(o *options) {
+		o.keepOnFailure = keep
+	}
+}
+
+// WithBackend 设置本次调用使用的媒体后端，默认为 DefaultBackend()
+func WithBackend(b MediaBackend) Option {
+	return func(o *options) {
+		if b != nil {
+			o.backend = b
+		}
+	}
+}
+
+// WithSampleRate 设置采样率 (例如 16000, 24000, 44100)
+func WithSampleRate(sampleRate int) Option {
+	return func(o *options) {
+		o.sampleRate = sampleRate
+	}
+}
+
+// WithNumChannels 设置声道数 (1: 单声道, 2: 双声道)
+func WithNumChannels(numChannels int) Option {
+	return func(o *options) {
+		o.numChannels = numChannels
+	}
+}
+
+// WithBitDepth 设置位深度 (通常是 16)
+func WithBitDepth(bitDepth int) Option {
+	return func(o *options) {
+		o.bitDepth = bitDepth
+	}
+}
+
+// WithFPS 设置抽帧时每秒输出的帧数
+func WithFPS(fps int) Option {
+	return func(o *options) {
+		o.fps = fps
+	}
+}
+
+// WithQuality 设置输出 JPEG 的质量，对应 ffmpeg 的 -qscale:v（2-31，越小质量越高）
+func WithQuality(quality int) Option {
+	return func(o *options) {
+		o.quality = quality
+	}
+}
+
+// WithSPSPPS 设置 base64 编码的 SPS/PPS，抽帧前会注入到 H.264 数据头部
+// 未设置时认为输入数据已自带 SPS/PPS
+func WithSPSPPS(b64SPS, b64PPS string) Option {
+	return func(o *options) {
+		o.sps, o.pps = b64SPS, b64PPS
+	}
+}
+
+func (o *options) frameParams() FrameParams {
+	return FrameParams{FPS: o.fps, Quality: o.quality}
+}
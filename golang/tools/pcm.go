@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"encoding/binary"
+)
+
+// 本文件提供 16 位小端 PCM（服务端收发的原始音频格式）与 float32 采样之间的转换、增益和混音。
+// 这些函数在每个会话的每一帧音频上都会执行，因此在小端平台上由 pcm_le.go 提供批量化的快速实现，
+// 其余平台（或使用 purego 构建标签时）退回到 pcm_generic.go 中的可移植实现，两者结果逐位一致。
+
+const s16Scale = 32768
+
+// PcmS16ToFloat32 将 16 位小端 PCM 转换为 [-1, 1) 区间的 float32 采样
+// dst 容量足够时会被复用，返回写入后的切片；pcm 末尾不足 2 字节的部分会被忽略
+func PcmS16ToFloat32(dst []float32, pcm []byte) []float32 {
+	dst = growFloat32(dst, len(pcm)/2)
+	s16ToF32(dst, pcm[:len(dst)*2])
+	return dst
+}
+
+// Float32ToPcmS16 将 float32 采样转换为 16 位小端 PCM，超出 [-1, 1] 的值会被饱和截断
+// dst 容量足够时会被复用，返回写入后的切片
+func Float32ToPcmS16(dst []byte, samples []float32) []byte {
+	dst = growBytes(dst, len(samples)*2)
+	f32ToS16(dst, samples)
+	return dst
+}
+
+// ApplyGainS16 原地对 16 位小端 PCM 施加线性增益，结果饱和截断到 int16 范围
+func ApplyGainS16(pcm []byte, gain float32) {
+	gainS16(pcm[:len(pcm)/2*2], gain)
+}
+
+// DownmixS16 将交错排列的多声道 16 位小端 PCM 取平均混为单声道
+// numChannels 小于等于 1 时直接复制；末尾不完整的帧会被忽略
+func DownmixS16(dst []byte, pcm []byte, numChannels int) []byte {
+	if numChannels <= 1 {
+		dst = growBytes(dst, len(pcm)/2*2)
+		copy(dst, pcm)
+		return dst
+	}
+	frames := len(pcm) / (2 * numChannels)
+	dst = growBytes(dst, frames*2)
+	downmixS16(dst, pcm[:frames*2*numChannels], numChannels)
+	return dst
+}
+
+func growFloat32(dst []float32, n int) []float32 {
+	if cap(dst) >= n {
+		return dst[:n]
+	}
+	return make([]float32, n)
+}
+
+func growBytes(dst []byte, n int) []byte {
+	if cap(dst) >= n {
+		return dst[:n]
+	}
+	return make([]byte, n)
+}
+
+// clampS16 将 float32 饱和截断到 int16 范围后四舍五入，NaN 视为 0
+// 平移到正数区间后再截断，避免按符号分支
+func clampS16(x float32) int16 {
+	switch {
+	case x != x:
+		return 0
+	case x > 32767:
+		x = 32767
+	case x < -32768:
+		x = -32768
+	}
+	return int16(int32(float64(x)+32768.5) - 32768)
+}
+
+func s16ToF32Generic(dst []float32, pcm []byte) {
+	for i := range dst {
+		dst[i] = float32(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / s16Scale
+	}
+}
+
+func f32ToS16Generic(dst []byte, samples []float32) {
+	for i, v := range samples {
+		binary.LittleEndian.PutUint16(dst[2*i:], uint16(clampS16(v*s16Scale)))
+	}
+}
+
+func gainS16Generic(pcm []byte, gain float32) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		v := float32(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(clampS16(v*gain)))
+	}
+}
+
+func downmixS16Generic(dst []byte, pcm []byte, numChannels int) {
+	stride := 2 * numChannels
+	for i := 0; i < len(dst)/2; i++ {
+		frame := pcm[i*stride : (i+1)*stride]
+		var sum int32
+		for c := 0; c < numChannels; c++ {
+			sum += int32(int16(binary.LittleEndian.Uint16(frame[2*c:])))
+		}
+		binary.LittleEndian.PutUint16(dst[2*i:], uint16(int16(sum/int32(numChannels))))
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !(386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm) || purego
+
+package tools
+
+func s16ToF32(dst []float32, pcm []byte) {
+	s16ToF32Generic(dst, pcm)
+}
+
+func f32ToS16(dst []byte, samples []float32) {
+	f32ToS16Generic(dst, samples)
+}
+
+func gainS16(pcm []byte, gain float32) {
+	gainS16Generic(pcm, gain)
+}
+
+func downmixS16(dst []byte, pcm []byte, numChannels int) {
+	downmixS16Generic(dst, pcm, numChannels)
+}
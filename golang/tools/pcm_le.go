@@ -0,0 +1,119 @@
+//go:build (386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm) && !purego
+
+package tools
+
+import (
+	"unsafe"
+)
+
+// 小端平台上 PCM 字节序与内存中的 int16 一致，可以直接把 []byte 视为 []int16 批量处理，
+// 省去逐个采样的字节拼装；每次处理 8 个采样以便编译器消除边界检查。
+// 切片起始地址未按 2 字节对齐时退回可移植实现。
+
+func asInt16s(b []byte) ([]int16, bool) {
+	if len(b) < 2 {
+		return nil, len(b) == 0
+	}
+	p := unsafe.Pointer(unsafe.SliceData(b))
+	if uintptr(p)%unsafe.Alignof(int16(0)) != 0 {
+		return nil, false
+	}
+	return unsafe.Slice((*int16)(p), len(b)/2), true
+}
+
+func s16ToF32(dst []float32, pcm []byte) {
+	src, ok := asInt16s(pcm)
+	if !ok {
+		s16ToF32Generic(dst, pcm)
+		return
+	}
+	src = src[:len(dst)]
+	for len(src) >= 8 {
+		s, d := src[:8:8], dst[:8:8]
+		d[0] = float32(s[0]) / s16Scale
+		d[1] = float32(s[1]) / s16Scale
+		d[2] = float32(s[2]) / s16Scale
+		d[3] = float32(s[3]) / s16Scale
+		d[4] = float32(s[4]) / s16Scale
+		d[5] = float32(s[5]) / s16Scale
+		d[6] = float32(s[6]) / s16Scale
+		d[7] = float32(s[7]) / s16Scale
+		src, dst = src[8:], dst[8:]
+	}
+	for i, v := range src {
+		dst[i] = float32(v) / s16Scale
+	}
+}
+
+func f32ToS16(dst []byte, samples []float32) {
+	out, ok := asInt16s(dst)
+	if !ok {
+		f32ToS16Generic(dst, samples)
+		return
+	}
+	out = out[:len(samples)]
+	for len(samples) >= 8 {
+		s, d := samples[:8:8], out[:8:8]
+		d[0] = clampS16(s[0] * s16Scale)
+		d[1] = clampS16(s[1] * s16Scale)
+		d[2] = clampS16(s[2] * s16Scale)
+		d[3] = clampS16(s[3] * s16Scale)
+		d[4] = clampS16(s[4] * s16Scale)
+		d[5] = clampS16(s[5] * s16Scale)
+		d[6] = clampS16(s[6] * s16Scale)
+		d[7] = clampS16(s[7] * s16Scale)
+		samples, out = samples[8:], out[8:]
+	}
+	for i, v := range samples {
+		out[i] = clampS16(v * s16Scale)
+	}
+}
+
+func gainS16(pcm []byte, gain float32) {
+	buf, ok := asInt16s(pcm)
+	if !ok {
+		gainS16Generic(pcm, gain)
+		return
+	}
+	for len(buf) >= 8 {
+		d := buf[:8:8]
+		d[0] = clampS16(float32(d[0]) * gain)
+		d[1] = clampS16(float32(d[1]) * gain)
+		d[2] = clampS16(float32(d[2]) * gain)
+		d[3] = clampS16(float32(d[3]) * gain)
+		d[4] = clampS16(float32(d[4]) * gain)
+		d[5] = clampS16(float32(d[5]) * gain)
+		d[6] = clampS16(float32(d[6]) * gain)
+		d[7] = clampS16(float32(d[7]) * gain)
+		buf = buf[8:]
+	}
+	for i, v := range buf {
+		buf[i] = clampS16(float32(v) * gain)
+	}
+}
+
+func downmixS16(dst []byte, pcm []byte, numChannels int) {
+	out, ok1 := asInt16s(dst)
+	src, ok2 := asInt16s(pcm)
+	if !ok1 || !ok2 {
+		downmixS16Generic(dst, pcm, numChannels)
+		return
+	}
+	if numChannels == 2 {
+		// 双声道是最常见的情况，单独展开
+		src = src[:len(out)*2]
+		for i := range out {
+			out[i] = int16((int32(src[2*i]) + int32(src[2*i+1])) / 2)
+		}
+		return
+	}
+	n := int32(numChannels)
+	for i := range out {
+		frame := src[i*numChannels : (i+1)*numChannels]
+		var sum int32
+		for _, v := range frame {
+			sum += int32(v)
+		}
+		out[i] = int16(sum / n)
+	}
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomPcm(n int) []byte {
+	r := rand.New(rand.NewSource(1))
+	pcm := make([]byte, n)
+	r.Read(pcm)
+	return pcm
+}
+
+// 快速实现必须与可移植实现逐位一致，包括未对齐的切片
+func TestPcmFastPathMatchesGeneric(t *testing.T) {
+	for _, offset := range []int{0, 1} {
+		buf := randomPcm(2*1027 + offset + 1)
+		pcm := buf[offset : offset+2*1027]
+
+		got := PcmS16ToFloat32(nil, pcm)
+		want := make([]float32, len(pcm)/2)
+		s16ToF32Generic(want, pcm)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("offset %d: PcmS16ToFloat32[%d] = %v, want %v", offset, i, got[i], want[i])
+			}
+		}
+
+		samples := append(got, 1.5, -1.5, float32(math.NaN()))
+		out := Float32ToPcmS16(make([]byte, 2*len(samples)+offset)[offset:offset], samples)
+		wantOut := make([]byte, 2*len(samples))
+		f32ToS16Generic(wantOut, samples)
+		if !bytes.Equal(out, wantOut) {
+			t.Fatalf("offset %d: Float32ToPcmS16 mismatch", offset)
+		}
+
+		for _, gain := range []float32{0, 0.5, 1, 3.7} {
+			g := append([]byte(nil), pcm...)
+			ApplyGainS16(g, gain)
+			w := append([]byte(nil), pcm...)
+			gainS16Generic(w, gain)
+			if !bytes.Equal(g, w) {
+				t.Fatalf("offset %d: ApplyGainS16(%v) mismatch", offset, gain)
+			}
+		}
+
+		for _, channels := range []int{2, 3, 6} {
+			frames := len(pcm) / (2 * channels)
+			got := DownmixS16(nil, pcm, channels)
+			want := make([]byte, frames*2)
+			downmixS16Generic(want, pcm[:frames*2*channels], channels)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("offset %d: DownmixS16(%d) mismatch", offset, channels)
+			}
+		}
+	}
+}
+
+func TestPcmRoundTrip(t *testing.T) {
+	pcm := make([]byte, 2*65536)
+	for i := 0; i < 65536; i++ {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(i))
+	}
+	out := Float32ToPcmS16(nil, PcmS16ToFloat32(nil, pcm))
+	if !bytes.Equal(out, pcm) {
+		t.Fatal("int16 -> float32 -> int16 round trip is not lossless")
+	}
+}
+
+func TestApplyGainS16Saturates(t *testing.T) {
+	pcm := make([]byte, 4)
+	binary.LittleEndian.PutUint16(pcm[0:], uint16(20000))
+	binary.LittleEndian.PutUint16(pcm[2:], uint16(0xffff-19999)) // -20000
+	ApplyGainS16(pcm, 2)
+	if got := int16(binary.LittleEndian.Uint16(pcm[0:])); got != math.MaxInt16 {
+		t.Errorf("positive sample = %d, want %d", got, math.MaxInt16)
+	}
+	if got := int16(binary.LittleEndian.Uint16(pcm[2:])); got != math.MinInt16 {
+		t.Errorf("negative sample = %d, want %d", got, math.MinInt16)
+	}
+}
+
+func TestDownmixS16Stereo(t *testing.T) {
+	pcm := make([]byte, 8)
+	for i, v := range []int16{100, 300, -5, -6} {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(v))
+	}
+	out := DownmixS16(nil, pcm, 2)
+	if len(out) != 4 {
+		t.Fatalf("len = %d, want 4", len(out))
+	}
+	if got := int16(binary.LittleEndian.Uint16(out[0:])); got != 200 {
+		t.Errorf("frame 0 = %d, want 200", got)
+	}
+	if got := int16(binary.LittleEndian.Uint16(out[2:])); got != -5 {
+		t.Errorf("frame 1 = %d, want -5", got)
+	}
+}
+
+func BenchmarkPcmS16ToFloat32(b *testing.B) {
+	pcm, dst := randomPcm(4800), make([]float32, 2400) // 24kHz 单声道 100ms
+	b.SetBytes(int64(len(pcm)))
+	for i := 0; i < b.N; i++ {
+		dst = PcmS16ToFloat32(dst, pcm)
+	}
+}
+
+func BenchmarkFloat32ToPcmS16(b *testing.B) {
+	samples, dst := PcmS16ToFloat32(nil, randomPcm(4800)), make([]byte, 4800)
+	b.SetBytes(int64(len(dst)))
+	for i := 0; i < b.N; i++ {
+		dst = Float32ToPcmS16(dst, samples)
+	}
+}
+
+func BenchmarkApplyGainS16(b *testing.B) {
+	pcm := randomPcm(4800)
+	b.SetBytes(int64(len(pcm)))
+	for i := 0; i < b.N; i++ {
+		ApplyGainS16(pcm, 0.8)
+	}
+}
+
+func BenchmarkDownmixS16(b *testing.B) {
+	pcm, dst := randomPcm(9600), make([]byte, 4800)
+	b.SetBytes(int64(len(pcm)))
+	for i := 0; i < b.N; i++ {
+		dst = DownmixS16(dst, pcm, 2)
+	}
+}
@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// 本文件提供基于 io.Reader/io.Writer 的流式版本，数据边读边写，不需要把整个文件放入内存，
+// 适用于代理、管道等场景。各函数的参数与配置项与对应的 []byte 版本一致。
+
+// ConcatWavStream 将多个参数相同的 WAV 流依次拼接写入 w，是 ConcatWavBytes 的流式版本
+// 会先读取全部输入的文件头以计算输出大小，再逐个复制音频数据；
+// 只有最后一个输入允许是大小未知的流式 WAV，此时输出的大小同样标记为未知
+func ConcatWavStream(w io.Writer, readers []io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+	if len(readers) == 0 {
+		return fmt.Errorf("%w: no wav data to concat", ErrEmptyInput)
+	}
+
+	headers := make([]*WavHeader, len(readers))
+	var dataSize int64
+	for i, r := range readers {
+		if err := o.ctx.Err(); err != nil {
+			return err
+		}
+		header, err := ReadWavHeader(r)
+		if err != nil {
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+		if first := headers[0]; first != nil &&
+			(header.SampleRate != first.SampleRate || header.NumChannels != first.NumChannels ||
+				header.BitDepth != first.BitDepth) {
+			return fmt.Errorf("%w: input %d is %d Hz/%d ch/%d bit, want %d Hz/%d ch/%d bit", ErrFormatMismatch, i,
+				header.SampleRate, header.NumChannels, header.BitDepth, first.SampleRate, first.NumChannels, first.BitDepth)
+		}
+		if header.AudioFormat != wavFormatPCM {
+			// 输出只有 16 字节的 PCM fmt 块，无法携带其他格式所需的扩展信息
+			return fmt.Errorf("%w: input %d has format %#x, only pcm can be concatenated", ErrInvalidWav, i, header.AudioFormat)
+		}
+		if header.DataSize < 0 && i != len(readers)-1 {
+			return fmt.Errorf("%w: input %d has unknown size but is not the last input", ErrInvalidWav, i)
+		}
+		headers[i] = header
+		if dataSize >= 0 {
+			if header.DataSize < 0 {
+				dataSize = -1
+			} else {
+				dataSize += header.DataSize
+			}
+		}
+	}
+
+	first := headers[0]
+	size := uint32(wavUnknownSize)
+	if dataSize >= 0 && dataSize < wavUnknownSize-wavHeaderSize {
+		size = uint32(dataSize)
+	}
+	var header [wavHeaderSize]byte
+	putWavHeader(header[:], first.SampleRate, first.NumChannels, first.BitDepth, size)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	for i, r := range readers {
+		if err := o.ctx.Err(); err != nil {
+			return err
+		}
+		if headers[i].DataSize < 0 {
+			if _, err := io.Copy(w, r); err != nil {
+				return fmt.Errorf("input %d: %w", i, err)
+			}
+			continue
+		}
+		if n, err := io.CopyN(w, r, headers[i].DataSize); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: input %d truncated after %d of %d bytes", ErrInvalidWav, i, n, headers[i].DataSize)
+			}
+			return fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	// data 块大小为奇数时需要补一个对齐字节
+	if size != wavUnknownSize && size%2 == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pcm2WavStream 为 r 中的 PCM 数据添加 WAV 文件头后写入 w，是 Pcm2Wav 的流式版本
+// dataSize 为 PCM 数据的字节数；传入负数表示大小未知，此时输出流式 WAV 并一直复制到 r 读取结束
+func Pcm2WavStream(w io.Writer, r io.Reader, dataSize int64, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return err
+	}
+	size := uint32(wavUnknownSize)
+	if dataSize >= 0 && dataSize < wavUnknownSize-wavHeaderSize {
+		size = uint32(dataSize)
+	}
+	var header [wavHeaderSize]byte
+	putWavHeader(header[:], o.sampleRate, o.numChannels, o.bitDepth, size)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if size == wavUnknownSize {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	if n, err := io.CopyN(w, r, dataSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: pcm data truncated after %d of %d bytes", io.ErrUnexpectedEOF, n, dataSize)
+		}
+		return err
+	}
+	// data 块大小为奇数时需要补一个对齐字节
+	if dataSize%2 == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractFramesStream 从 r 读取 H.264 数据交给媒体后端抽帧，每解码出一张 JPEG 就调用一次 onFrame，
+// 是 ExtractFramesToBase64 的流式版本，不会产生临时文件
+// onFrame 返回错误时停止抽帧并返回该错误；传给 onFrame 的切片在回调返回后仍可安全持有
+func ExtractFramesStream(r io.Reader, onFrame func(frame []byte) error, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return err
+	}
+	if o.sps != "" || o.pps != "" {
+		prefix, err := InjectSPSPPS(nil, o.sps, o.pps)
+		if err != nil {
+			return err
+		}
+		r = io.MultiReader(bytes.NewReader(prefix), r)
+	}
+
+	count := 0
+	err := o.backend.ExtractFrames(o.ctx, r, o.frameParams(), func(frame []byte) error {
+		count++
+		return onFrame(frame)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Successfully extracted %d frames.", count)
+	return nil
+}
+
+// maxJPEGSize 限制单帧 JPEG 的大小，防止异常输出耗尽内存
+const maxJPEGSize = 32 << 20
+
+var errInvalidJPEG = fmt.Errorf("%w: invalid jpeg in output", ErrFFmpegFailed)
+
+// readJPEG 从 image2pipe 输出中按 JPEG 标记切分出一张完整图片
+// 流正好结束时返回 io.EOF
+func readJPEG(br *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errInvalidJPEG
+		}
+		return nil, err
+	}
+	if soi != [2]byte{0xFF, 0xD8} {
+		return nil, errInvalidJPEG
+	}
+	frame := append(make([]byte, 0, 64<<10), soi[:]...)
+
+	readByte := func() (byte, error) {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return 0, errInvalidJPEG
+		}
+		if err == nil && len(frame) >= maxJPEGSize {
+			return 0, errInvalidJPEG
+		}
+		return b, err
+	}
+
+	inScan := false
+	for {
+		b, err := readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != 0xFF {
+			if !inScan {
+				return nil, errInvalidJPEG
+			}
+			frame = append(frame, b)
+			continue
+		}
+		marker, err := readByte()
+		if err != nil {
+			return nil, err
+		}
+		for marker == 0xFF { // 填充字节
+			if marker, err = readByte(); err != nil {
+				return nil, err
+			}
+		}
+		frame = append(frame, 0xFF, marker)
+		switch {
+		case marker == 0x00 && inScan: // 熵编码数据中转义的 0xFF
+		case marker >= 0xD0 && marker <= 0xD7 && inScan: // RSTn
+		case marker == 0xD9: // EOI
+			return frame, nil
+		case marker == 0x00 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8):
+			return nil, errInvalidJPEG
+		default:
+			// 带长度的段，长度包含自身的 2 字节
+			var l [2]byte
+			if _, err := io.ReadFull(br, l[:]); err != nil {
+				return nil, errInvalidJPEG
+			}
+			n := int(binary.BigEndian.Uint16(l[:]))
+			if n < 2 || len(frame)+n > maxJPEGSize {
+				return nil, errInvalidJPEG
+			}
+			frame = append(frame, l[:]...)
+			start := len(frame)
+			frame = append(frame, make([]byte, n-2)...)
+			if _, err := io.ReadFull(br, frame[start:]); err != nil {
+				return nil, errInvalidJPEG
+			}
+			inScan = marker == 0xDA // SOS 之后是熵编码数据
+		}
+	}
+}
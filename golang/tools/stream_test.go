@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+func TestConcatWavStreamMatchesBytes(t *testing.T) {
+	var inputs [][]byte
+	for _, n := range []int{480, 960, 2} {
+		wavData, err := Pcm2Wav(randomPcm(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, wavData)
+	}
+	want, err := ConcatWavBytes(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var readers []io.Reader
+	for _, in := range inputs {
+		readers = append(readers, bytes.NewReader(in))
+	}
+	var got bytes.Buffer
+	if err := ConcatWavStream(&got, readers); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("ConcatWavStream output (%d bytes) differs from ConcatWavBytes (%d bytes)", got.Len(), len(want))
+	}
+}
+
+func TestConcatWavStreamErrors(t *testing.T) {
+	a, _ := Pcm2Wav(randomPcm(10))
+	b, _ := Pcm2Wav(randomPcm(10), WithSampleRate(16000))
+
+	err := ConcatWavStream(io.Discard, []io.Reader{bytes.NewReader(a), bytes.NewReader(b)})
+	if !errors.Is(err, ErrFormatMismatch) {
+		t.Errorf("mismatched rates: err = %v, want ErrFormatMismatch", err)
+	}
+	err = ConcatWavStream(io.Discard, []io.Reader{bytes.NewReader(a[:len(a)-1])})
+	if !errors.Is(err, ErrInvalidWav) {
+		t.Errorf("truncated data: err = %v, want ErrInvalidWav", err)
+	}
+	err = ConcatWavStream(io.Discard, []io.Reader{bytes.NewReader([]byte("RIFF"))})
+	if !errors.Is(err, ErrInvalidWav) {
+		t.Errorf("truncated header: err = %v, want ErrInvalidWav", err)
+	}
+	if err = ConcatWavStream(io.Discard, nil); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("no input: err = %v, want ErrEmptyInput", err)
+	}
+}
+
+func TestPcm2WavStream(t *testing.T) {
+	pcm := randomPcm(1000)
+	want, _ := Pcm2Wav(pcm, WithSampleRate(16000), WithNumChannels(2))
+
+	var got bytes.Buffer
+	if err := Pcm2WavStream(&got, bytes.NewReader(pcm), int64(len(pcm)), WithSampleRate(16000), WithNumChannels(2)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatal("Pcm2WavStream output differs from Pcm2Wav")
+	}
+
+	// 大小未知时输出流式 WAV，解析后 DataSize 为 -1
+	got.Reset()
+	if err := Pcm2WavStream(&got, bytes.NewReader(pcm), -1); err != nil {
+		t.Fatal(err)
+	}
+	header, err := ReadWavHeader(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.DataSize != -1 || got.Len() != len(pcm) {
+		t.Fatalf("DataSize = %d, remaining = %d, want -1 and %d", header.DataSize, got.Len(), len(pcm))
+	}
+
+	if err := Pcm2WavStream(io.Discard, bytes.NewReader(pcm), int64(len(pcm)+1)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("short input: err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+// extensibleWav 构造 WAVE_FORMAT_EXTENSIBLE 格式的 16 位单声道 WAV，subFormat 为子格式 GUID 的格式编码
+func extensibleWav(subFormat uint16, pcm []byte) []byte {
+	fmtChunk := make([]byte, 40)
+	binary.LittleEndian.PutUint16(fmtChunk[0:2], wavFormatExtensible)
+	binary.LittleEndian.PutUint16(fmtChunk[2:4], 1)
+	binary.LittleEndian.PutUint32(fmtChunk[4:8], 16000)
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], 32000)
+	binary.LittleEndian.PutUint16(fmtChunk[12:14], 2)
+	binary.LittleEndian.PutUint16(fmtChunk[14:16], 16)
+	binary.LittleEndian.PutUint16(fmtChunk[16:18], 22) // cbSize
+	binary.LittleEndian.PutUint16(fmtChunk[18:20], 16) // 有效位数
+	binary.LittleEndian.PutUint32(fmtChunk[20:24], 4)  // 声道掩码：前中
+	binary.LittleEndian.PutUint16(fmtChunk[24:26], subFormat)
+	copy(fmtChunk[26:40], "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71")
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+len(fmtChunk)+8+len(pcm)))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(fmtChunk)))
+	b.Write(fmtChunk)
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+func TestConcatWavStreamFormats(t *testing.T) {
+	pcm := randomPcm(64)
+	want, _ := Pcm2Wav(append(append([]byte{}, pcm...), pcm...), WithSampleRate(16000))
+
+	// 子格式为 PCM 的扩展格式输出为标准 PCM 文件头
+	var got bytes.Buffer
+	if err := ConcatWavStream(&got, []io.Reader{bytes.NewReader(extensibleWav(1, pcm)), bytes.NewReader(extensibleWav(1, pcm))}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("extensible pcm: output differs from Pcm2Wav of the joined data")
+	}
+
+	// 其他格式无法用 16 字节的 fmt 块表示
+	float, _ := Pcm2Wav(pcm, WithSampleRate(16000))
+	binary.LittleEndian.PutUint16(float[20:22], 3)
+	for name, input := range map[string][]byte{"float": float, "extensible float": extensibleWav(3, pcm)} {
+		if err := ConcatWavStream(io.Discard, []io.Reader{bytes.NewReader(input)}); !errors.Is(err, ErrInvalidWav) {
+			t.Errorf("%s: err = %v, want ErrInvalidWav", name, err)
+		}
+	}
+}
+
+func TestWavOddDataSize(t *testing.T) {
+	// 8 位单声道数据可以是奇数字节，data 块后补一个对齐字节，该字节计入 RIFF 大小
+	pcm := randomPcm(5)
+	opts := []Option{WithBitDepth(8)}
+	check := func(name string, wavData []byte, dataSize int) {
+		t.Helper()
+		if riff := binary.LittleEndian.Uint32(wavData[4:8]); int(riff) != len(wavData)-8 {
+			t.Errorf("%s: RIFF size = %d, file is %d bytes", name, riff, len(wavData))
+		}
+		if len(wavData) != wavHeaderSize+dataSize+1 || wavData[len(wavData)-1] != 0 {
+			t.Errorf("%s: %d bytes, want %d data bytes and a zero pad byte", name, len(wavData), dataSize)
+		}
+		header, err := ReadWavHeader(bytes.NewReader(wavData))
+		if err != nil || header.DataSize != int64(dataSize) {
+			t.Errorf("%s: ReadWavHeader() = %+v, %v", name, header, err)
+		}
+	}
+
+	wavData, _ := Pcm2Wav(pcm, opts...)
+	check("Pcm2Wav", wavData, len(pcm))
+	var stream bytes.Buffer
+	if err := Pcm2WavStream(&stream, bytes.NewReader(pcm), int64(len(pcm)), opts...); err != nil {
+		t.Fatal(err)
+	}
+	check("Pcm2WavStream", stream.Bytes(), len(pcm))
+
+	// 拼接时跳过输入的对齐字节，输出按总大小重新对齐
+	odd, _ := Pcm2Wav(randomPcm(4)[:3], opts...)
+	var concat bytes.Buffer
+	if err := ConcatWavStream(&concat, []io.Reader{bytes.NewReader(wavData), bytes.NewReader(odd), bytes.NewReader(odd)}); err != nil {
+		t.Fatal(err)
+	}
+	check("ConcatWavStream", concat.Bytes(), len(pcm)+3+3)
+}
+
+func TestReadJPEGSplitsConcatenatedImages(t *testing.T) {
+	var stream bytes.Buffer
+	var want [][]byte
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+		for p := range img.Pix {
+			img.Pix[p] = uint8(p*7 + i*31) // 造出熵编码数据中的 0xFF
+		}
+		img.Set(0, 0, color.White)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, buf.Bytes())
+		stream.Write(buf.Bytes())
+	}
+
+	br := bufio.NewReader(&stream)
+	for i := range want {
+		frame, err := readJPEG(br)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !bytes.Equal(frame, want[i]) {
+			t.Fatalf("frame %d: got %d bytes, want %d bytes", i, len(frame), len(want[i]))
+		}
+	}
+	if _, err := readJPEG(br); err != io.EOF {
+		t.Fatalf("after last frame: err = %v, want io.EOF", err)
+	}
+
+	if _, err := readJPEG(bufio.NewReader(bytes.NewReader(want[0][:len(want[0])-10]))); !errors.Is(err, ErrFFmpegFailed) {
+		t.Fatalf("truncated frame: err = %v, want ErrFFmpegFailed", err)
+	}
+}
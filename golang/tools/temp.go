@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// 媒体函数创建的临时文件与目录的命名规则，SweepTempDir 只会清理匹配这些规则的项
+const (
+	tempWavPattern    = "output-*.wav"    // ConcatWavBytes 的中间文件
+	tempFramesPattern = "video_process_*" // ExtractFramesToBase64 的工作目录
+)
+
+var defaultTempDir atomic.Value // string
+
+// SetDefaultTempDir 设置未通过 WithTempDir 指定时使用的临时目录，可指向 tmpfs 或容量更大的卷
+// dir 为空表示恢复为 os.TempDir()，并发调用安全
+func SetDefaultTempDir(dir string) {
+	defaultTempDir.Store(dir)
+}
+
+// DefaultTempDir 返回当前的默认临时目录
+func DefaultTempDir() string {
+	if dir, _ := defaultTempDir.Load().(string); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+// removeTemp 清理临时文件或目录；处理失败且设置了 WithKeepTempOnFailure 时保留并记录路径
+func (o *options) removeTemp(path string, failed bool) {
+	if failed && o.keepOnFailure {
+		log.Printf("keeping temp artifacts for debugging: %s", path)
+		return
+	}
+	if err := os.RemoveAll(path); err != nil {
+		log.Printf("failed to remove temp path: %v", err)
+	}
+}
+
+// SweepTempDir 清理进程崩溃等原因遗留在 dir 中的临时文件，dir 为空时使用 DefaultTempDir()：
+//   - output-*.wav 文件：ConcatWavBytes 的中间文件
+//   - video_process_* 目录：ExtractFramesToBase64 写入输入码流的工作目录（不存在单独的 video_frames_* 目录）
+//
+// 只删除修改时间早于 olderThan 之前的项，以免误删其他正在运行的进程的临时文件；返回被删除的路径
+func SweepTempDir(dir string, olderThan time.Duration) ([]string, error) {
+	if dir == "" {
+		dir = DefaultTempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var removed []string
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		wav, _ := filepath.Match(tempWavPattern, name)
+		frames, _ := filepath.Match(tempFramesPattern, name)
+		if !(wav && !entry.IsDir()) && !(frames && entry.IsDir()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// 已被其他进程删除
+			continue
+		}
+		if !info.ModTime().Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, path)
+	}
+	return removed, errors.Join(errs...)
+}
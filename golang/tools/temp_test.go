@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestConcatWavBytesRemovesTempFile(t *testing.T) {
+	dir := t.TempDir()
+	wav := readTestdata(t, "sine_16000_16_mono.wav")
+	if _, err := ConcatWavBytes([][]byte{wav, wav}, WithTempDir(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if names := listDir(t, dir); len(names) != 0 {
+		t.Errorf("temp dir contains %v after ConcatWavBytes", names)
+	}
+}
+
+func TestExtractFramesKeepTempOnFailure(t *testing.T) {
+	// 非法的 SPS/PPS 在创建临时目录之后才会被发现
+	for _, keep := range []bool{false, true} {
+		dir := t.TempDir()
+		_, err := ExtractFramesToBase64([]byte{0, 0, 0, 1, 0x65}, WithTempDir(dir), WithSPSPPS("!", "!"), WithKeepTempOnFailure(keep))
+		if !errors.Is(err, ErrInvalidSPSPPS) {
+			t.Fatalf("err = %v, want ErrInvalidSPSPPS", err)
+		}
+		names := listDir(t, dir)
+		if keep && (len(names) != 1 || !matchName(tempFramesPattern, names[0])) {
+			t.Errorf("keep=true: temp dir contains %v, want one kept work dir", names)
+		}
+		if !keep && len(names) != 0 {
+			t.Errorf("keep=false: temp dir contains %v, want empty", names)
+		}
+	}
+}
+
+func matchName(pattern, name string) bool {
+	ok, _ := filepath.Match(pattern, name)
+	return ok
+}
+
+func TestSetDefaultTempDir(t *testing.T) {
+	dir := t.TempDir()
+	SetDefaultTempDir(dir)
+	defer SetDefaultTempDir("")
+	if got := DefaultTempDir(); got != dir {
+		t.Fatalf("DefaultTempDir() = %q, want %q", got, dir)
+	}
+	_, _ = ExtractFramesToBase64(nil, WithSPSPPS("!", "!"), WithKeepTempOnFailure(true))
+	if names := listDir(t, dir); len(names) != 1 {
+		t.Errorf("default temp dir contains %v, want the kept work dir", names)
+	}
+
+	SetDefaultTempDir("")
+	if got := DefaultTempDir(); got != os.TempDir() {
+		t.Errorf("DefaultTempDir() after reset = %q, want %q", got, os.TempDir())
+	}
+}
+
+func TestSweepTempDir(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	create := func(name string, isDir bool, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		var err error
+		if isDir {
+			if err = os.Mkdir(path, 0o755); err == nil {
+				err = os.WriteFile(filepath.Join(path, "frame_0001.jpg"), []byte{0xFF, 0xD8}, 0o644)
+			}
+		} else {
+			err = os.WriteFile(path, []byte("RIFF"), 0o644)
+		}
+		if err == nil {
+			err = os.Chtimes(path, modTime, modTime)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	create("output-123.wav", false, old)
+	create("video_process_456", true, old)
+	create("output-789.wav", false, time.Now()) // 仍可能在使用中
+	create("video_process_file", false, old)    // 不是目录
+	create("output-dir.wav", true, old)         // 不是文件
+	create("other.wav", false, old)
+
+	removed, err := SweepTempDir(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "output-123.wav"), filepath.Join(dir, "video_process_456")}
+	slices.Sort(removed)
+	if !slices.Equal(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	wantLeft := []string{"other.wav", "output-789.wav", "output-dir.wav", "video_process_file"}
+	if left := listDir(t, dir); !slices.Equal(left, wantLeft) {
+		t.Errorf("left = %v, want %v", left, wantLeft)
+	}
+
+	if _, err := SweepTempDir(filepath.Join(dir, "missing"), 0); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SweepTempDir(missing) err = %v, want ErrNotExist", err)
+	}
+}
@@ -3,35 +3,53 @@ package tools
 import (
 	"bytes"
 	"encoding/base64"
-	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
 )
 
-func ConcatWavBytes(wavBytes [][]byte) ([]byte, error) {
+// ConcatWavBytes 将多段参数相同的 WAV 数据拼接为一个 WAV 文件
+// data 块大小未知（0xFFFFFFFF，如 Pcm2WavStream 流式写出的 WAV）的输入取到该段数据末尾
+// 可通过 WithTempDir 指定中间文件所在目录，中间文件在返回前删除
+func ConcatWavBytes(wavBytes [][]byte, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
 	var combinedFrames []audio.IntBuffer
 	var params *audio.Format
 	var bitDepth int
 
-	for _, wavData := range wavBytes {
+	for i, wavData := range wavBytes {
+		if err := o.ctx.Err(); err != nil {
+			return nil, err
+		}
 
+		// 先校验文件头，避免畸形的块大小导致解码器分配超大内存
 		wavReader := bytes.NewReader(wavData)
+		header, err := ReadWavHeader(wavReader)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		switch {
+		case header.DataSize < 0:
+			// 大小未知（0xFFFFFFFF）的 data 块取到输入末尾，解码器不支持该写法，先改写为实际大小
+			wavData = withDataSize(wavData, len(wavData)-wavReader.Len())
+		case header.DataSize > int64(wavReader.Len()):
+			return nil, fmt.Errorf("%w: input %d declares %d data bytes but has %d", ErrInvalidWav, i, header.DataSize, wavReader.Len())
+		}
+		wavReader.Reset(wavData)
 		decoder := wav.NewDecoder(wavReader)
 
 		if !decoder.IsValidFile() {
-			return nil, fmt.Errorf("invalid WAV file")
+			return nil, fmt.Errorf("%w: input %d has no valid header", ErrInvalidWav, i)
 		}
 
 		buf, err := decoder.FullPCMBuffer()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: input %d: %w", ErrInvalidWav, i, err)
 		}
 
 		if params == nil {
@@ -40,7 +58,8 @@ func ConcatWavBytes(wavBytes [][]byte) ([]byte, error) {
 			currentParams := buf.Format
 			if params.SampleRate != currentParams.SampleRate ||
 				params.NumChannels != currentParams.NumChannels {
-				return nil, fmt.Errorf("所有 WAV 文件的参数必须相同")
+				return nil, fmt.Errorf("%w: input %d is %d Hz/%d ch, want %d Hz/%d ch", ErrFormatMismatch, i,
+					currentParams.SampleRate, currentParams.NumChannels, params.SampleRate, params.NumChannels)
 			}
 		}
 
@@ -48,20 +67,28 @@ func ConcatWavBytes(wavBytes [][]byte) ([]byte, error) {
 		bitDepth = int(decoder.BitDepth)
 	}
 	if params == nil {
-		return nil, fmt.Errorf("拼接音频失败，params 为空")
+		return nil, fmt.Errorf("%w: no wav data to concat", ErrEmptyInput)
+	}
+
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	// 创建一个临时文件
-	tempFile, err := os.CreateTemp("", "output-*.wav")
+	tempFile, err := os.CreateTemp(o.tempDir, tempWavPattern)
 	if err != nil {
 		return nil, err
 	}
-	defer tempFile.Close() // 确保文件会被关闭
+	defer func() { o.removeTemp(tempFile.Name(), err != nil) }()
+	defer tempFile.Close() // 确保文件在删除前被关闭
 
 	encoder := wav.NewEncoder(tempFile, params.SampleRate, bitDepth, params.NumChannels, 1)
 
 	// 合并所有帧数据
 	for _, buffer := range combinedFrames {
+		if err := o.ctx.Err(); err != nil {
+			return nil, err
+		}
 		if err := encoder.Write(&buffer); err != nil {
 			return nil, err
 		}
@@ -72,7 +99,9 @@ func ConcatWavBytes(wavBytes [][]byte) ([]byte, error) {
 	}
 
 	// 读取临时文件的数据到内存中
-	tempFile.Seek(0, io.SeekStart)
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
 	outputBuffer, err := io.ReadAll(tempFile)
 	if err != nil {
 		return nil, err
@@ -82,130 +111,84 @@ func ConcatWavBytes(wavBytes [][]byte) ([]byte, error) {
 }
 
 // Pcm2Wav 将 PCM 数据转换为 WAV 格式，通过添加 WAV 文件头
-// sampleRate: 采样率 (例如 16000, 44100)
-// numChannels: 声道数 (1: 单声道, 2: 双声道)
-// bitDepth: 位深度 (通常是 16)
-func Pcm2Wav(pcmBytes []byte, sampleRate, numChannels, bitDepth int) ([]byte, error) {
-	// WAV 文件头大小为 44 字节
-	headerSize := 44
-	fileSize := len(pcmBytes) + headerSize
-
-	// 创建包含文件头的字节切片
-	wavData := make([]byte, fileSize)
-
-	// 1. RIFF 头
-	copy(wavData[0:4], []byte("RIFF"))
-	// 2. 文件大小 (文件总字节数 - 8)
-	binary.LittleEndian.PutUint32(wavData[4:8], uint32(fileSize-8))
-	// 3. WAVE 标记
-	copy(wavData[8:12], []byte("WAVE"))
-	// 4. fmt 子块
-	copy(wavData[12:16], []byte("fmt "))
-	// 5. fmt 子块大小 (16 表示 PCM 格式)
-	binary.LittleEndian.PutUint32(wavData[16:20], 16)
-	// 6. 音频格式 (1 表示 PCM)
-	binary.LittleEndian.PutUint16(wavData[20:22], 1)
-	// 7. 声道数
-	binary.LittleEndian.PutUint16(wavData[22:24], uint16(numChannels))
-	// 8. 采样率
-	binary.LittleEndian.PutUint32(wavData[24:28], uint32(sampleRate))
-	// 9. 字节率 (采样率 * 通道数 * 位深度 / 8)
-	binary.LittleEndian.PutUint32(wavData[28:32], uint32(sampleRate*numChannels*bitDepth/8))
-	// 10. 数据块对齐 (通道数 * 位深度 / 8)
-	binary.LittleEndian.PutUint16(wavData[32:34], uint16(numChannels*bitDepth/8))
-	// 11. 位深度
-	binary.LittleEndian.PutUint16(wavData[34:36], uint16(bitDepth))
-	// 12. data 子块
-	copy(wavData[36:40], []byte("data"))
-	// 13. 数据大小
-	binary.LittleEndian.PutUint32(wavData[40:44], uint32(len(pcmBytes)))
+// 默认按 24000Hz、单声道、16 位处理，可通过 WithSampleRate、WithNumChannels、WithBitDepth 修改
+func Pcm2Wav(pcmBytes []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
+	}
+	// 创建包含文件头的字节切片，数据为奇数字节时末尾保留一个对齐字节
+	wavData := make([]byte, wavHeaderSize+len(pcmBytes)+len(pcmBytes)%2)
+	putWavHeader(wavData, o.sampleRate, o.numChannels, o.bitDepth, uint32(len(pcmBytes)))
 
 	// 复制 PCM 数据
-	copy(wavData[44:], pcmBytes)
+	copy(wavData[wavHeaderSize:], pcmBytes)
 
 	return wavData, nil
 }
 
-// ExtractFramesToBase64 接收 base64 编码的 H.264 数据，返回抽帧后图片的 base64 数组
-func ExtractFramesToBase64(data []byte, spsB64, ppsB64 string) ([][]byte, error) {
-	var images [][]byte
+// ExtractFramesToBase64 接收 H.264 数据，返回抽帧后的 JPEG 图片数组
+// 默认每秒抽取 2 帧，可通过 WithFPS、WithQuality、WithSPSPPS、WithTempDir、WithKeepTempOnFailure、WithContext、WithBackend 调整
+func ExtractFramesToBase64(data []byte, opts ...Option) (images [][]byte, err error) {
+	o := newOptions(opts)
+	if err := o.ctx.Err(); err != nil {
+		return nil, err
+	}
 	// 创建临时目录
-	tempDir, err := os.MkdirTemp("", "video_process_*")
+	tempDir, err := os.MkdirTemp(o.tempDir, tempFramesPattern)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %v", err)
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
 	}
-	defer func(path string) {
-		err := os.RemoveAll(path)
-		if err != nil {
-			log.Printf("failed to remove temp dir: %v", err)
-		}
-	}(tempDir) // 自动清理
+	defer func() { o.removeTemp(tempDir, err != nil) }() // 自动清理
 
 	// 1. 解码 base64 到 .h264 文件
 	h264Path := filepath.Join(tempDir, "input.h264")
 	// 注入 SPS/PPS
-	fixedData, err := InjectSPSPPS(data, spsB64, ppsB64)
-	if err != nil {
-		log.Fatal(err)
+	fixedData := data
+	if o.sps != "" || o.pps != "" {
+		if fixedData, err = InjectSPSPPS(data, o.sps, o.pps); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := os.WriteFile(h264Path, fixedData, 0644); err != nil {
-		return nil, fmt.Errorf("write h264 file failed: %v", err)
+		return nil, fmt.Errorf("write h264 file failed: %w", err)
 	}
 
-	// 2. 设置输出帧路径
-	framePattern := filepath.Join(tempDir, "frame_%04d.jpg")
-
-	// 3. 调用 ffmpeg 抽帧
-	cmd := exec.Command(
-		"ffmpeg",
-		"-f", "h264",
-		"-i", h264Path,
-		"-vf", "fps=2", // 每秒 2 帧
-		"-qscale:v", "2", // 高质量 JPEG
-		"-y", // 允许覆盖
-		framePattern,
-	)
-
-	// 捕获输出用于调试（可选）
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	log.Printf("Running command: %v", cmd.Args)
-	err = cmd.Run()
+	// 2. 交给媒体后端抽帧，输入文件保留在临时目录中，失败时可通过 WithKeepTempOnFailure 留存排查
+	input, err := os.Open(h264Path)
 	if err != nil {
-		return nil, fmt.Errorf("ffmpeg execution failed: %v", err)
+		return nil, fmt.Errorf("open h264 file failed: %w", err)
 	}
-
-	// 4. 查找所有生成的 jpg 文件并转为 base64
-	matches, err := filepath.Glob(filepath.Join(tempDir, "frame_*.jpg"))
+	defer input.Close()
+	err = o.backend.ExtractFrames(o.ctx, input, o.frameParams(), func(frame []byte) error {
+		images = append(images, frame)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("glob pattern error: %v", err)
-	}
-
-	// 按文件名排序（保证顺序）
-	sortFiles(matches)
-
-	for _, imgPath := range matches {
-		imgData, err := os.ReadFile(imgPath) // 替代 ioutil.ReadFile
-		if err != nil {
-			return nil, fmt.Errorf("read image file failed: %v", err)
-		}
-		images = append(images, imgData)
+		return nil, err
 	}
 
 	log.Printf("Successfully extracted %d frames.", len(images))
 	return images, nil
 }
 
+// InjectSPSPPS 在 H.264 数据前注入 base64 编码的 SPS/PPS，SPS/PPS 无法解码或类型不符时返回 ErrInvalidSPSPPS
 func InjectSPSPPS(rawH264 []byte, b64SPS, b64PPS string) ([]byte, error) {
 	sps, err := base64.StdEncoding.DecodeString(b64SPS)
 	if err != nil {
-		return nil, fmt.Errorf("decode SPS failed: %v", err)
+		return nil, fmt.Errorf("%w: decode sps: %w", ErrInvalidSPSPPS, err)
 	}
 	pps, err := base64.StdEncoding.DecodeString(b64PPS)
 	if err != nil {
-		return nil, fmt.Errorf("decode PPS failed: %v", err)
+		return nil, fmt.Errorf("%w: decode pps: %w", ErrInvalidSPSPPS, err)
+	}
+	// SPS/PPS 来自客户端上传，交给 ffmpeg 之前先校验 NAL 类型
+	if nalType(sps) != nalTypeSPS {
+		return nil, fmt.Errorf("%w: nal type %d is not sps", ErrInvalidSPSPPS, nalType(sps))
+	}
+	if nalType(pps) != nalTypePPS {
+		return nil, fmt.Errorf("%w: nal type %d is not pps", ErrInvalidSPSPPS, nalType(pps))
 	}
 
 	// 构造完整数据：[start code][SPS][start code][PPS][原始数据]
@@ -225,14 +208,16 @@ func InjectSPSPPS(rawH264 []byte, b64SPS, b64PPS string) ([]byte, error) {
 	return result, nil
 }
 
-// sortFiles 简单排序文件名（如 frame_0001.jpg, frame_0002.jpg）
-func sortFiles(files []string) {
-	// 使用标准库排序
-	for i := 0; i < len(files); i++ {
-		for j := i + 1; j < len(files); j++ {
-			if files[i] > files[j] {
-				files[i], files[j] = files[j], files[i]
-			}
-		}
+// H.264 NAL 单元类型
+const (
+	nalTypeSPS = 7
+	nalTypePPS = 8
+)
+
+// nalType 返回不含起始码的 NAL 单元的类型，nal 为空时返回 0
+func nalType(nal []byte) int {
+	if len(nal) == 0 {
+		return 0
 	}
+	return int(nal[0] & 0x1F)
 }
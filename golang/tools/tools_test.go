@@ -1,24 +1,334 @@
 package tools
 
 import (
-	"encoding/base64"
-	"fmt"
-	"log"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/jpeg"
+	"io"
+	"os/exec"
+	"strings"
 	"testing"
 )
 
+func TestReadWavHeaderFixtures(t *testing.T) {
+	for _, f := range sineFixtures {
+		header, err := ReadWavHeader(bytes.NewReader(readTestdata(t, f.name)))
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		wantSize := int64(f.sampleRate * fixtureSineMs / 1000 * f.numChannel * f.bitDepth / 8)
+		if header.SampleRate != f.sampleRate || header.BitDepth != f.bitDepth ||
+			header.NumChannels != f.numChannel || header.AudioFormat != 1 || header.DataSize != wantSize {
+			t.Errorf("%s: header = %+v, want %d Hz/%d bit/%d ch/%d bytes", f.name, header,
+				f.sampleRate, f.bitDepth, f.numChannel, wantSize)
+		}
+	}
+}
+
+func TestConcatWavBytesGolden(t *testing.T) {
+	for _, f := range sineFixtures {
+		input := readTestdata(t, f.name)
+		got, err := ConcatWavBytes([][]byte{input, input})
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		checkGolden(t, "concat_"+strings.TrimSuffix(f.name, ".wav")+".golden.wav", got)
+
+		// 拼接结果应为同一文件头加上两段原始 PCM 数据
+		pcm := input[wavHeaderSize:]
+		if !bytes.Equal(got[wavHeaderSize:], append(append([]byte(nil), pcm...), pcm...)) {
+			t.Errorf("%s: concatenated pcm data differs from inputs", f.name)
+		}
+		if size := binary.LittleEndian.Uint32(got[40:44]); int(size) != 2*len(pcm) {
+			t.Errorf("%s: data size = %d, want %d", f.name, size, 2*len(pcm))
+		}
+
+		var stream bytes.Buffer
+		if err := ConcatWavStream(&stream, []io.Reader{bytes.NewReader(input), bytes.NewReader(input)}); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if !bytes.Equal(stream.Bytes(), got) {
+			t.Errorf("%s: ConcatWavStream output differs from ConcatWavBytes", f.name)
+		}
+	}
+}
+
+// Pcm2Wav 与 Pcm2WavStream 不依赖 ffmpeg，输出逐字节比较；夹具本身由 Pcm2Wav 生成，
+// 文件头的正确性由 TestPcm2WavHeaderBytes 对照手写字节检查
+func TestPcm2WavGolden(t *testing.T) {
+	for _, f := range sineFixtures {
+		name := strings.TrimSuffix(f.name, ".wav")
+		pcm := readTestdata(t, f.name)[wavHeaderSize:]
+		opts := []Option{WithSampleRate(f.sampleRate), WithBitDepth(f.bitDepth), WithNumChannels(f.numChannel)}
+		got, err := Pcm2Wav(pcm, opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+
+		var stream bytes.Buffer
+		if err := Pcm2WavStream(&stream, bytes.NewReader(pcm), int64(len(pcm)), opts...); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if !bytes.Equal(stream.Bytes(), got) {
+			t.Errorf("%s: Pcm2WavStream output differs from Pcm2Wav", f.name)
+		}
+
+		// 大小未知时 RIFF 与 data 块大小写为 0xFFFFFFFF
+		stream.Reset()
+		if err := Pcm2WavStream(&stream, bytes.NewReader(pcm), -1, opts...); err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		checkGolden(t, "stream_"+name+".golden.wav", stream.Bytes())
+	}
+}
+
+// 文件头与按 WAV 规范手写的字节比较，不依赖 Pcm2Wav 生成的夹具
+func TestPcm2WavHeaderBytes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		dataSize int
+		opts     []Option
+		header   string
+	}{
+		{"44100 Hz 16 bit stereo", 17640, []Option{WithSampleRate(44100), WithNumChannels(2)},
+			"RIFF\x0c\x45\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x02\x00\x44\xac\x00\x00\x10\xb1\x02\x00\x04\x00\x10\x00data\xe8\x44\x00\x00"},
+		{"48000 Hz 24 bit stereo", 28800, []Option{WithSampleRate(48000), WithBitDepth(24), WithNumChannels(2)},
+			"RIFF\xa4\x70\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x02\x00\x80\xbb\x00\x00\x00\x65\x04\x00\x06\x00\x18\x00data\x80\x70\x00\x00"},
+		// 奇数字节的 data 块后补一个 0，RIFF 大小计入该字节：36 + 799 + 1
+		{"8000 Hz 8 bit mono, odd size", 799, []Option{WithSampleRate(8000), WithBitDepth(8)},
+			"RIFF\x44\x03\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\x40\x1f\x00\x00\x40\x1f\x00\x00\x01\x00\x08\x00data\x1f\x03\x00\x00"},
+	} {
+		pcm := bytes.Repeat([]byte{0x5a}, tc.dataSize)
+		want := append([]byte(tc.header), pcm...)
+		if tc.dataSize%2 == 1 {
+			want = append(want, 0)
+		}
+		got, err := Pcm2Wav(pcm, tc.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: Pcm2Wav header = %x, want %x (%d bytes, want %d)", tc.name, got[:wavHeaderSize], want[:wavHeaderSize], len(got), len(want))
+		}
+		var stream bytes.Buffer
+		if err := Pcm2WavStream(&stream, bytes.NewReader(pcm), int64(len(pcm)), tc.opts...); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !bytes.Equal(stream.Bytes(), want) {
+			t.Errorf("%s: Pcm2WavStream output differs from the expected bytes", tc.name)
+		}
+	}
+
+	// 大小未知时 RIFF 与 data 块大小均为 0xFFFFFFFF
+	var stream bytes.Buffer
+	if err := Pcm2WavStream(&stream, bytes.NewReader(nil), -1); err != nil {
+		t.Fatal(err)
+	}
+	want := "RIFF\xff\xff\xff\xffWAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00\xc0\x5d\x00\x00\x80\xbb\x00\x00\x02\x00\x10\x00data\xff\xff\xff\xff"
+	if stream.String() != want {
+		t.Errorf("streaming header = %x, want %x", stream.Bytes(), want)
+	}
+}
+
+// 奇数字节的 data 块补对齐字节，拼接时重新对齐
+func TestOddDataSizeGolden(t *testing.T) {
+	pcm := readTestdata(t, "sine_8000_8_mono.wav")[wavHeaderSize:]
+	pcm = pcm[:len(pcm)-1]
+	got, err := Pcm2Wav(pcm, WithSampleRate(8000), WithBitDepth(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "pcm2wav_odd_8000_8_mono.golden.wav", got)
+
+	var stream bytes.Buffer
+	if err := Pcm2WavStream(&stream, bytes.NewReader(pcm), int64(len(pcm)), WithSampleRate(8000), WithBitDepth(8)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stream.Bytes(), got) {
+		t.Error("Pcm2WavStream output differs from Pcm2Wav")
+	}
+
+	stream.Reset()
+	if err := ConcatWavStream(&stream, []io.Reader{bytes.NewReader(got), bytes.NewReader(got), bytes.NewReader(got)}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "concat_odd_8000_8_mono.golden.wav", stream.Bytes())
+}
+
+func TestConcatWavBytesErrors(t *testing.T) {
+	a, b := readTestdata(t, "sine_16000_16_mono.wav"), readTestdata(t, "sine_24000_16_mono.wav")
+	if _, err := ConcatWavBytes([][]byte{a, b}); !errors.Is(err, ErrFormatMismatch) {
+		t.Errorf("mismatched rates: err = %v, want ErrFormatMismatch", err)
+	}
+	if _, err := ConcatWavBytes([][]byte{a, []byte("not a wav file")}); !errors.Is(err, ErrInvalidWav) {
+		t.Errorf("invalid input: err = %v, want ErrInvalidWav", err)
+	}
+	if _, err := ConcatWavBytes(nil); !errors.Is(err, ErrEmptyInput) {
+		t.Errorf("no input: err = %v, want ErrEmptyInput", err)
+	}
+}
+
+// 声明的 data 块大小超出输入时返回 ErrInvalidWav，大小未知时取到输入末尾
+func TestConcatWavBytesDataSize(t *testing.T) {
+	input := readTestdata(t, "sine_16000_16_mono.wav")
+	want, err := ConcatWavBytes([][]byte{input, input})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stream bytes.Buffer
+	if err := Pcm2WavStream(&stream, bytes.NewReader(input[wavHeaderSize:]), -1, WithSampleRate(16000)); err != nil {
+		t.Fatal(err)
+	}
+	unknown := stream.Bytes()
+	got, err := ConcatWavBytes([][]byte{unknown, input})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unknown data size: output (%d bytes) differs from concatenating the sized input (%d bytes)", len(got), len(want))
+	}
+	if binary.LittleEndian.Uint32(unknown[40:44]) != wavUnknownSize {
+		t.Error("ConcatWavBytes modified its input")
+	}
+
+	oversized := bytes.Clone(input)
+	binary.LittleEndian.PutUint32(oversized[40:44], uint32(len(input)))
+	if _, err := ConcatWavBytes([][]byte{input, oversized}); !errors.Is(err, ErrInvalidWav) {
+		t.Errorf("oversized data chunk: err = %v, want ErrInvalidWav", err)
+	}
+}
+
+func TestInjectSPSPPS(t *testing.T) {
+	slices := readTestdata(t, fixtureSlices)
+	sps, pps := string(readTestdata(t, fixtureSPSFile)), string(readTestdata(t, fixturePPSFile))
+	got, err := InjectSPSPPS(slices, sps, pps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, readTestdata(t, fixtureH264)) {
+		t.Error("InjectSPSPPS output differs from tiny.h264")
+	}
+	if _, err := InjectSPSPPS(slices, "not base64!", pps); !errors.Is(err, ErrInvalidSPSPPS) {
+		t.Errorf("invalid sps: err = %v, want ErrInvalidSPSPPS", err)
+	}
+}
+
+// SPS/PPS 的 NAL 类型不符时在调用媒体后端之前返回 ErrInvalidSPSPPS
+func TestInjectSPSPPSRejectsWrongNALType(t *testing.T) {
+	slices := readTestdata(t, fixtureSlices)
+	sps, pps := string(readTestdata(t, fixtureSPSFile)), string(readTestdata(t, fixturePPSFile))
+	for _, tc := range []struct {
+		name     string
+		sps, pps string
+	}{
+		{"swapped", pps, sps},
+		{"slice as sps", b64(slices[4:20]), pps},
+		{"empty pps", sps, ""},
+	} {
+		if _, err := InjectSPSPPS(slices, tc.sps, tc.pps); !errors.Is(err, ErrInvalidSPSPPS) {
+			t.Errorf("%s: err = %v, want ErrInvalidSPSPPS", tc.name, err)
+		}
+		b := &fakeBackend{}
+		if _, err := ExtractFramesToBase64(slices, WithBackend(b), WithSPSPPS(tc.sps, tc.pps)); !errors.Is(err, ErrInvalidSPSPPS) {
+			t.Errorf("%s: ExtractFramesToBase64 err = %v, want ErrInvalidSPSPPS", tc.name, err)
+		}
+		if b.input != nil {
+			t.Errorf("%s: backend called with %d bytes", tc.name, len(b.input))
+		}
+	}
+}
+
+// 校验 MP4 夹具的顶层结构，以及 stco 指向的第一个 sample 恰好是第一帧
+func TestMP4FixtureLayout(t *testing.T) {
+	data := readTestdata(t, fixtureMP4)
+	var types []string
+	for off := 0; off < len(data); {
+		size := int(binary.BigEndian.Uint32(data[off:]))
+		types = append(types, string(data[off+4:off+8]))
+		off += size
+	}
+	if strings.Join(types, ",") != "ftyp,mdat,moov" {
+		t.Fatalf("top level boxes = %v", types)
+	}
+	_, _, frames := h264Fixture()
+	i := bytes.Index(data, []byte("stco"))
+	offset := binary.BigEndian.Uint32(data[i+12:])
+	if n := binary.BigEndian.Uint32(data[offset:]); int(n) != len(frames[0]) {
+		t.Fatalf("first sample length = %d, want %d", n, len(frames[0]))
+	}
+}
+
+func requireFFmpeg(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not found in PATH")
+	}
+}
+
+func checkFrames(t *testing.T, frames [][]byte) {
+	t.Helper()
+	if len(frames) == 0 {
+		t.Fatal("no frames extracted")
+	}
+	for i, frame := range frames {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if cfg.Width != fixtureWidth || cfg.Height != fixtureHeight {
+			t.Errorf("frame %d: size = %dx%d, want %dx%d", i, cfg.Width, cfg.Height, fixtureWidth, fixtureHeight)
+		}
+	}
+}
+
 func TestExtractFramesToBase64(t *testing.T) {
-	video := ""
-	// 解码为 []byte
-	data, err := base64.StdEncoding.DecodeString(video)
+	requireFFmpeg(t)
+	slices := readTestdata(t, fixtureSlices)
+	sps, pps := string(readTestdata(t, fixtureSPSFile)), string(readTestdata(t, fixturePPSFile))
+
+	frames, err := ExtractFramesToBase64(slices, WithSPSPPS(sps, pps), WithTempDir(t.TempDir()))
 	if err != nil {
-		log.Fatal("解码失败：", err)
+		t.Fatal(err)
 	}
-	frames, err := ExtractFramesToBase64(data, "Z0LADJoFAAABMA==", "aM48gA==")
+	checkFrames(t, frames)
+
+	// 自带 SPS/PPS 的码流无需注入
+	full, err := ExtractFramesToBase64(readTestdata(t, fixtureH264), WithTempDir(t.TempDir()))
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+	if len(full) != len(frames) {
+		t.Errorf("frames from full stream = %d, want %d", len(full), len(frames))
 	}
-	for _, frame := range frames {
-		fmt.Println(frame)
+}
+
+func TestExtractFramesStream(t *testing.T) {
+	requireFFmpeg(t)
+	slices := readTestdata(t, fixtureSlices)
+	sps, pps := string(readTestdata(t, fixtureSPSFile)), string(readTestdata(t, fixturePPSFile))
+
+	want, err := ExtractFramesToBase64(slices, WithSPSPPS(sps, pps), WithTempDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames [][]byte
+	err = ExtractFramesStream(bytes.NewReader(slices), func(frame []byte) error {
+		frames = append(frames, frame)
+		return nil
+	}, WithSPSPPS(sps, pps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFrames(t, frames)
+	if len(frames) != len(want) {
+		t.Errorf("ExtractFramesStream frames = %d, want %d", len(frames), len(want))
+	}
+
+	stop := errors.New("stop")
+	err = ExtractFramesStream(bytes.NewReader(slices), func([]byte) error { return stop }, WithSPSPPS(sps, pps))
+	if !errors.Is(err, stop) {
+		t.Errorf("callback error: err = %v, want %v", err, stop)
 	}
 }
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// wavHeaderSize 为 Pcm2Wav 等函数写出的标准 PCM WAV 文件头大小
+const wavHeaderSize = 44
+
+// wavFormatPCM、wavFormatExtensible 为 fmt 块中的格式编码
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavUnknownSize 是流式输出时 RIFF/data 块大小的占位值，表示长度未知、读取到 EOF 为止
+const wavUnknownSize = 0xFFFFFFFF
+
+// WavHeader 描述 WAV 文件的格式信息
+type WavHeader struct {
+	AudioFormat uint16 // 1 表示 PCM；WAVE_FORMAT_EXTENSIBLE 时为子格式的编码，即 PCM 同样记为 1
+	NumChannels int
+	SampleRate  int
+	BitDepth    int
+	// DataSize 为 data 块的字节数，为 -1 表示大小未知（流式写出的 WAV），数据读取到 EOF 为止
+	DataSize int64
+}
+
+// ReadWavHeader 从 r 中解析 WAV 文件头，跳过 fmt 与 data 之外的块
+// 成功返回时 r 恰好位于 data 块的数据起始处
+func ReadWavHeader(r io.Reader) (*WavHeader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("%w: read riff header: %w", ErrInvalidWav, err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: missing RIFF/WAVE signature", ErrInvalidWav)
+	}
+
+	var header *WavHeader
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("%w: read chunk header: %w", ErrInvalidWav, err)
+		}
+		id, size := string(chunk[0:4]), binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("%w: fmt chunk too small (%d bytes)", ErrInvalidWav, size)
+			}
+			var f [16]byte
+			if _, err := io.ReadFull(r, f[:]); err != nil {
+				return nil, fmt.Errorf("%w: read fmt chunk: %w", ErrInvalidWav, err)
+			}
+			header = &WavHeader{
+				AudioFormat: binary.LittleEndian.Uint16(f[0:2]),
+				NumChannels: int(binary.LittleEndian.Uint16(f[2:4])),
+				SampleRate:  int(binary.LittleEndian.Uint32(f[4:8])),
+				BitDepth:    int(binary.LittleEndian.Uint16(f[14:16])),
+			}
+			if header.NumChannels == 0 || header.SampleRate == 0 || header.BitDepth == 0 || header.BitDepth%8 != 0 {
+				return nil, fmt.Errorf("%w: unsupported format %d Hz/%d ch/%d bit", ErrInvalidWav,
+					header.SampleRate, header.NumChannels, header.BitDepth)
+			}
+			rest := int64(size) - 16
+			if header.AudioFormat == wavFormatExtensible && size >= 40 {
+				// 扩展部分依次为 cbSize、有效位数、声道掩码与子格式 GUID，GUID 的前 2 字节为格式编码
+				var ext [24]byte
+				if _, err := io.ReadFull(r, ext[:]); err != nil {
+					return nil, fmt.Errorf("%w: read fmt extension: %w", ErrInvalidWav, err)
+				}
+				header.AudioFormat = binary.LittleEndian.Uint16(ext[8:10])
+				rest -= 24
+			}
+			if err := skipChunk(r, rest); err != nil {
+				return nil, err
+			}
+		case "data":
+			if header == nil {
+				return nil, fmt.Errorf("%w: data chunk before fmt chunk", ErrInvalidWav)
+			}
+			header.DataSize = int64(size)
+			if size == wavUnknownSize {
+				header.DataSize = -1
+			}
+			return header, nil
+		default:
+			if err := skipChunk(r, int64(size)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// withDataSize 返回 wavData 的副本，并把 RIFF 大小和 data 块大小改写为实际大小，
+// dataOffset 为 data 块内容的起始偏移
+func withDataSize(wavData []byte, dataOffset int) []byte {
+	out := bytes.Clone(wavData)
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	binary.LittleEndian.PutUint32(out[dataOffset-4:dataOffset], uint32(len(out)-dataOffset))
+	return out
+}
+
+// skipChunk 跳过 size 字节的块内容及其对齐填充字节
+func skipChunk(r io.Reader, size int64) error {
+	if size%2 == 1 {
+		size++
+	}
+	if n, err := io.CopyN(io.Discard, r, size); err != nil {
+		return fmt.Errorf("%w: chunk truncated after %d of %d bytes: %w", ErrInvalidWav, n, size, err)
+	}
+	return nil
+}
+
+// putWavHeader 向 dst 前 44 字节写入 PCM WAV 文件头
+// dataSize 为奇数时 RIFF 大小包含 data 块之后的对齐字节，该字节由调用方写出
+func putWavHeader(dst []byte, sampleRate, numChannels, bitDepth int, dataSize uint32) {
+	riffSize := uint32(wavUnknownSize)
+	if padded := uint64(dataSize) + uint64(dataSize%2); dataSize != wavUnknownSize && padded <= wavUnknownSize-(wavHeaderSize-8) {
+		riffSize = uint32(padded) + wavHeaderSize - 8
+	}
+
+	// 1. RIFF 头
+	copy(dst[0:4], "RIFF")
+	// 2. 文件大小 (文件总字节数 - 8)
+	binary.LittleEndian.PutUint32(dst[4:8], riffSize)
+	// 3. WAVE 标记
+	copy(dst[8:12], "WAVE")
+	// 4. fmt 子块
+	copy(dst[12:16], "fmt ")
+	// 5. fmt 子块大小 (16 表示 PCM 格式)
+	binary.LittleEndian.PutUint32(dst[16:20], 16)
+	// 6. 音频格式 (1 表示 PCM)
+	binary.LittleEndian.PutUint16(dst[20:22], wavFormatPCM)
+	// 7. 声道数
+	binary.LittleEndian.PutUint16(dst[22:24], uint16(numChannels))
+	// 8. 采样率
+	binary.LittleEndian.PutUint32(dst[24:28], uint32(sampleRate))
+	// 9. 字节率 (采样率 * 通道数 * 位深度 / 8)
+	binary.LittleEndian.PutUint32(dst[28:32], uint32(sampleRate*numChannels*bitDepth/8))
+	// 10. 数据块对齐 (通道数 * 位深度 / 8)
+	binary.LittleEndian.PutUint16(dst[32:34], uint16(numChannels*bitDepth/8))
+	// 11. 位深度
+	binary.LittleEndian.PutUint16(dst[34:36], uint16(bitDepth))
+	// 12. data 子块
+	copy(dst[36:40], "data")
+	// 13. 数据大小
+	binary.LittleEndian.PutUint32(dst[40:44], dataSize)
+}